@@ -0,0 +1,246 @@
+package yadi
+
+import (
+	stdErrors "errors"
+	"github.com/pkg/errors"
+	"github.com/xbl4de/yadi/types"
+	"github.com/xbl4de/yadi/utils"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+)
+
+// Bind builds T from the values stored under prefix. Each struct field is read
+// from prefix.FieldName, prefix.fieldName or the relative path of its tag.
+// All missing and invalid values are reported at once.
+func Bind[T any](ctx types.Context, prefix string) (T, error) {
+	var zeroValue T
+	value, err := getTypedValue(ctx, prefix, reflect.TypeFor[T]())
+	if err != nil {
+		return zeroValue, errors.WithMessagef(err, "Failed to bind values by prefix: %s", prefix)
+	}
+	return value.(T), nil
+}
+
+// getTypedValue reads the value at path converted to typ, with placeholders
+// resolved. Structs are bound field by field from the values under path.
+func getTypedValue(ctx types.Context, path string, typ reflect.Type) (interface{}, error) {
+	return getBoundValue(ctx, path, typ, nil)
+}
+
+// getBoundValue is getTypedValue for a value nested in the struct types of
+// binding, which are being bound on the way to path.
+func getBoundValue(ctx types.Context, path string, typ reflect.Type, binding []reflect.Type) (interface{}, error) {
+	if isOptionalType(typ) {
+		return newOptional(typ, func(valueType reflect.Type) (interface{}, error) {
+			return getBoundValue(ctx, path, valueType, binding)
+		})
+	}
+	value, err := ctx.GetGenericValue(path)
+	if isSectionType(typ) && !(err == nil && isDirectlyConvertible(value, typ)) {
+		section, bindErr := bindSection(ctx, path, typ, binding)
+		if bindErr != nil && err == nil {
+			// Values are stored under path, so the section is not missing
+			// even if some of its fields are.
+			return nil, newBuildError(bindErr)
+		}
+		return section, bindErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	if isSectionCollectionType(typ) && !isDirectlyConvertible(value, typ) {
+		return bindSections(ctx, path, typ, value, binding)
+	}
+	value, err = resolvePlaceholders(ctx, path, value)
+	if err != nil {
+		return nil, err
+	}
+	converted, err := convertValue(value, typ)
+	if err != nil {
+		if origin, originErr := ctx.ValueOrigin(path); originErr == nil {
+			return nil, errors.WithMessagef(err, "value from %s", origin)
+		}
+		return nil, err
+	}
+	return converted, nil
+}
+
+// isSectionType reports whether typ is a struct, or a pointer to one, read
+// field by field rather than parsed from a single value.
+func isSectionType(typ reflect.Type) bool {
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return typ.Kind() == reflect.Struct && !isTextType(typ) && !isOptionalType(typ)
+}
+
+// isDirectlyConvertible reports whether value is converted as a whole rather
+// than bound field by field.
+func isDirectlyConvertible(value interface{}, typ reflect.Type) bool {
+	valueType := reflect.TypeOf(value)
+	if valueType == nil {
+		return false
+	}
+	_, ok := findConverter(valueType, typ)
+	return ok || valueType.AssignableTo(typ)
+}
+
+// isBinding reports whether the struct of the section type typ is being bound.
+func isBinding(binding []reflect.Type, typ reflect.Type) bool {
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return slices.Contains(binding, typ)
+}
+
+func isSectionCollectionType(typ reflect.Type) bool {
+	return (typ.Kind() == reflect.Slice || typ.Kind() == reflect.Map) && isSectionType(typ.Elem())
+}
+
+func bindSection(ctx types.Context, path string, typ reflect.Type, binding []reflect.Type) (interface{}, error) {
+	structType := typ
+	if typ.Kind() == reflect.Ptr {
+		structType = typ.Elem()
+	}
+	binding = append(slices.Clip(binding), structType)
+	result := reflect.New(structType)
+	var errs []error
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		err := bindField(ctx, path, field, result.Elem().Field(i), binding)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, stdErrors.Join(errs...)
+	}
+	if typ.Kind() == reflect.Ptr {
+		return result.Interface(), nil
+	}
+	return result.Elem().Interface(), nil
+}
+
+func bindField(ctx types.Context, path string, field reflect.StructField, fieldValue reflect.Value, binding []reflect.Type) error {
+	yadiTag, err := types.ParseTag(field.Tag.Get(types.TagName))
+	if err != nil {
+		return errors.WithMessagef(err, "%s", joinPath(path, field.Name))
+	}
+	if shouldIgnoreInjection(yadiTag, field.Type) {
+		return nil
+	}
+	if yadiTag.BeanName != "" {
+		return errors.Wrapf(types.ErrInjectNotSupported, "%s: beans cannot be bound from values", joinPath(path, field.Name))
+	}
+	fieldPath, found := findFieldPath(ctx, path, field, yadiTag)
+	value, envFound, err := getEnvTagValue(ctx, yadiTag, fieldPath, field.Type)
+	if envFound {
+		if err != nil {
+			return errors.WithMessagef(err, "%s", fieldPath)
+		}
+		fieldValue.Set(reflect.ValueOf(value))
+		return nil
+	}
+	switch {
+	case !found && yadiTag.HasDefault:
+		value, err = convertValue(yadiTag.DefaultValue, field.Type)
+	case !found && yadiTag.Optional:
+		return nil
+	case !found && !isSectionType(field.Type):
+		return errors.Wrapf(types.ErrNoValueFound, "%s", fieldPath)
+	case !found && isBinding(binding, field.Type):
+		// A missing section of a type bound above it would be bound forever,
+		// pointers to it are left nil.
+		if field.Type.Kind() == reflect.Ptr {
+			return nil
+		}
+		return errors.Wrapf(types.ErrNoValueFound, "%s", fieldPath)
+	default:
+		value, err = getBoundValue(ctx, fieldPath, field.Type, binding)
+	}
+	if err != nil {
+		if isSectionType(field.Type) {
+			return err
+		}
+		return errors.WithMessagef(err, "%s", fieldPath)
+	}
+	fieldValue.Set(reflect.ValueOf(value))
+	return nil
+}
+
+// findFieldPath returns the path the field is read from and whether there is
+// a value at it.
+func findFieldPath(ctx types.Context, path string, field reflect.StructField, yadiTag *types.Tag) (string, bool) {
+	if yadiTag.ValuePath != "" {
+		fieldPath := joinPath(path, yadiTag.ValuePath)
+		return fieldPath, hasValue(ctx, fieldPath)
+	}
+	for _, key := range []string{field.Name, utils.LowerCamel(field.Name)} {
+		fieldPath := joinPath(path, key)
+		if hasValue(ctx, fieldPath) {
+			return fieldPath, true
+		}
+	}
+	return joinPath(path, field.Name), false
+}
+
+func bindSections(ctx types.Context, path string, typ reflect.Type, value interface{}, binding []reflect.Type) (interface{}, error) {
+	var errs []error
+	var result reflect.Value
+	switch items := value.(type) {
+	case []interface{}:
+		if typ.Kind() != reflect.Slice {
+			return nil, conversionError(value, typ, nil)
+		}
+		result = reflect.MakeSlice(typ, len(items), len(items))
+		for i := range items {
+			item, err := getBoundValue(ctx, joinPath(path, strconv.Itoa(i)), typ.Elem(), binding)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			result.Index(i).Set(reflect.ValueOf(item))
+		}
+	case map[string]interface{}:
+		if typ.Kind() != reflect.Map {
+			return nil, conversionError(value, typ, nil)
+		}
+		result = reflect.MakeMapWithSize(typ, len(items))
+		for _, key := range slices.Sorted(maps.Keys(items)) {
+			convertedKey, err := convertString(key, typ.Key())
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			item, err := getBoundValue(ctx, joinPath(path, key), typ.Elem(), binding)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			result.SetMapIndex(convertedKey, reflect.ValueOf(item))
+		}
+	default:
+		return nil, conversionError(value, typ, nil)
+	}
+	if len(errs) > 0 {
+		return nil, stdErrors.Join(errs...)
+	}
+	return result.Interface(), nil
+}
+
+func hasValue(ctx types.Context, path string) bool {
+	_, err := ctx.GetGenericValue(path)
+	return err == nil
+}
+
+func joinPath(prefix string, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
@@ -0,0 +1,184 @@
+package yadi
+
+import (
+	stdErrors "errors"
+	g "github.com/onsi/gomega"
+	"github.com/xbl4de/yadi/types"
+	"testing"
+	"time"
+)
+
+type PoolConfig struct {
+	Size    int
+	Timeout time.Duration `yadi:"path=timeout;default=5s"`
+}
+
+type ReplicaConfig struct {
+	Host string
+	Port int `yadi:"optional"`
+}
+
+type DatabaseConfig struct {
+	Host     string
+	Port     int
+	UserName string `yadi:"path=user"`
+	Pool     PoolConfig
+	Replicas []ReplicaConfig           `yadi:"optional"`
+	Shards   map[string]*ReplicaConfig `yadi:"optional"`
+	Secret   string                    `yadi:"ignore"`
+	internal string
+}
+
+type DatabaseRepository struct {
+	Config *DatabaseConfig `yadi:"path=db"`
+}
+
+func NewDatabaseRepository(config DatabaseConfig) *DatabaseRepository {
+	return &DatabaseRepository{Config: &config}
+}
+
+func provideDatabaseValues() {
+	SetValue("db", map[string]any{
+		"Host": "localhost",
+		"port": "5432",
+		"user": "admin",
+		"pool": map[string]any{"size": 10},
+		"replicas": []any{
+			map[string]any{"host": "replica-1", "port": 5433},
+			map[string]any{"host": "replica-2"},
+		},
+	})
+	SetValue("db.shards.eu.host", "eu-1")
+}
+
+func TestBindValues_Success(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	provideDatabaseValues()
+	UseLazyContext()
+
+	config, err := BindValues[DatabaseConfig]("db")
+
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(config.Host).Should(g.Equal("localhost"))
+	g.Expect(config.Port).Should(g.Equal(5432))
+	g.Expect(config.UserName).Should(g.Equal("admin"))
+	g.Expect(config.Pool).Should(g.Equal(PoolConfig{Size: 10, Timeout: 5 * time.Second}))
+	g.Expect(config.Replicas).Should(g.Equal([]ReplicaConfig{
+		{Host: "replica-1", Port: 5433},
+		{Host: "replica-2"},
+	}))
+	g.Expect(config.Shards).Should(g.Equal(map[string]*ReplicaConfig{"eu": {Host: "eu-1"}}))
+	g.Expect(config.Secret).Should(g.BeEmpty())
+}
+
+func TestBindValues_ToPointer(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	provideDatabaseValues()
+	UseLazyContext()
+
+	config, err := BindValues[*PoolConfig]("db.pool")
+
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(config).Should(g.Equal(&PoolConfig{Size: 10, Timeout: 5 * time.Second}))
+}
+
+func TestBindValues_ReportsAllErrors(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	SetValue("db.port", "http")
+	SetValue("db.replicas.0.port", 1)
+	UseLazyContext()
+
+	_, err := BindValues[DatabaseConfig]("db")
+
+	g.Expect(err).Should(g.MatchError(types.ErrNoValueFound))
+	g.Expect(err).Should(g.MatchError(types.ErrValueConversion))
+	g.Expect(err.Error()).Should(g.ContainSubstring("db.Host"))
+	g.Expect(err.Error()).Should(g.ContainSubstring("db.port"))
+	g.Expect(err.Error()).Should(g.ContainSubstring("db.user"))
+	g.Expect(err.Error()).Should(g.ContainSubstring("db.Pool.Size"))
+	g.Expect(err.Error()).Should(g.ContainSubstring("db.replicas.0.Host"))
+	g.Expect(err.Error()).ShouldNot(g.ContainSubstring("db.Pool.timeout"))
+	var joined interface{ Unwrap() []error }
+	g.Expect(stdErrors.As(err, &joined)).Should(g.BeTrue())
+}
+
+func TestBindValues_InjectedAsField(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	provideDatabaseValues()
+	UseLazyContext()
+
+	repository, err := GetBean[*DatabaseRepository]()
+
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(repository.Config.UserName).Should(g.Equal("admin"))
+	g.Expect(repository.Config.Pool.Size).Should(g.Equal(10))
+}
+
+func TestBindValues_InjectedAsFuncArg(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	provideDatabaseValues()
+	SetBeanProviderFunc[*DatabaseRepository](NewDatabaseRepository, WithValuePathAt(0, "db"))
+	UseLazyContext()
+
+	repository, err := GetBean[*DatabaseRepository]()
+
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(repository.Config.Host).Should(g.Equal("localhost"))
+}
+
+func TestBindValues_StoredStruct(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	SetValue("db.pool", PoolConfig{Size: 3})
+	UseLazyContext()
+
+	config, err := BindValues[PoolConfig]("db.pool")
+
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(config).Should(g.Equal(PoolConfig{Size: 3}))
+}
+
+type LinkedNode struct {
+	Name string
+	Next *LinkedNode
+}
+
+type ParentNode struct {
+	Name  string
+	Child *ChildNode
+}
+
+type ChildNode struct {
+	Parent ParentNode
+}
+
+func TestBind_SelfReferentialType(t *testing.T) {
+	t.Parallel()
+	gt := g.NewWithT(t)
+	c := NewContainer()
+	c.SetValue("n.name", "first")
+	c.SetValue("n.next.name", "second")
+
+	node, err := Bind[LinkedNode](c, "n")
+
+	gt.Expect(err).ShouldNot(g.HaveOccurred())
+	gt.Expect(node.Name).Should(g.Equal("first"))
+	gt.Expect(node.Next).Should(g.Equal(&LinkedNode{Name: "second"}))
+}
+
+func TestBind_MutuallyReferentialTypes(t *testing.T) {
+	t.Parallel()
+	gt := g.NewWithT(t)
+	c := NewContainer()
+	c.SetValue("p.name", "root")
+
+	_, err := Bind[ParentNode](c, "p")
+
+	gt.Expect(err).Should(g.MatchError(types.ErrNoValueFound))
+	gt.Expect(err.Error()).Should(g.ContainSubstring("p.Child.Parent"))
+}
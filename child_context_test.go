@@ -0,0 +1,111 @@
+package yadi
+
+import (
+	"context"
+	g "github.com/onsi/gomega"
+	"github.com/xbl4de/yadi/types"
+	"testing"
+)
+
+func TestChildContext_FallsBackToParentBean(t *testing.T) {
+	t.Parallel()
+	gt := g.NewWithT(t)
+	parent := newTestContainer()
+	child := parent.Child()
+
+	parentE := Require[*ServiceE](parent)
+	childE, err := Get[*ServiceE](child)
+
+	gt.Expect(err).ShouldNot(g.HaveOccurred())
+	gt.Expect(childE).Should(g.BeIdenticalTo(parentE))
+}
+
+func TestChildContext_UsesParentProvider(t *testing.T) {
+	t.Parallel()
+	gt := g.NewWithT(t)
+	parent := newTestContainer()
+	err := ProvideFunc[*ServiceE](parent, NewServiceE, WithDefaultValueAt(0, "parent"))
+	gt.Expect(err).ShouldNot(g.HaveOccurred())
+	child := parent.Child()
+
+	childE := Require[*ServiceE](child)
+	parentE := Require[*ServiceE](parent)
+
+	gt.Expect(childE.Description).Should(g.Equal("parent"))
+	gt.Expect(childE).Should(g.BeIdenticalTo(parentE))
+}
+
+func TestChildContext_ProviderShadowsParent(t *testing.T) {
+	t.Parallel()
+	gt := g.NewWithT(t)
+	parent := newTestContainer()
+	err := ProvideFunc[*ServiceE](parent, NewServiceE, WithDefaultValueAt(0, "parent"))
+	gt.Expect(err).ShouldNot(g.HaveOccurred())
+	child := parent.Child()
+	err = ProvideFunc[*ServiceE](child, NewServiceE, WithDefaultValueAt(0, "child"))
+	gt.Expect(err).ShouldNot(g.HaveOccurred())
+
+	gt.Expect(Require[*ServiceE](child).Description).Should(g.Equal("child"))
+	gt.Expect(Require[*ServiceE](parent).Description).Should(g.Equal("parent"))
+	gt.Expect(Require[*ServiceA](child).ServiceE.Description).Should(g.Equal("child"))
+}
+
+func TestChildContext_ParentBeanResolvesDependenciesInParent(t *testing.T) {
+	t.Parallel()
+	gt := g.NewWithT(t)
+	parent := newTestContainer()
+	err := ProvideFunc[*ServiceA](parent, NewServiceA, WithDefaultValueAt(0, "parent"))
+	gt.Expect(err).ShouldNot(g.HaveOccurred())
+	child := parent.Child()
+	err = ProvideFunc[*ServiceE](child, NewServiceE, WithDefaultValueAt(0, "child"))
+	gt.Expect(err).ShouldNot(g.HaveOccurred())
+
+	serviceA := Require[*ServiceA](child)
+
+	gt.Expect(serviceA.ServiceE.Description).Should(g.Equal(ServiceEDescription))
+}
+
+func TestChildContext_Values(t *testing.T) {
+	t.Parallel()
+	gt := g.NewWithT(t)
+	parent := newTestContainer()
+	child := parent.Child()
+	child.SetValue("serviceE.description", "child")
+
+	description, err := Value[string](child, "serviceE.description")
+	gt.Expect(err).ShouldNot(g.HaveOccurred())
+	gt.Expect(description).Should(g.Equal("child"))
+
+	description, err = Value[string](parent, "serviceE.description")
+	gt.Expect(err).ShouldNot(g.HaveOccurred())
+	gt.Expect(description).Should(g.Equal(ServiceEDescription))
+
+	count, err := Value[int](child, "serviceF.count")
+	gt.Expect(err).ShouldNot(g.HaveOccurred())
+	gt.Expect(count).Should(g.Equal(ServiceFCount))
+
+	_, err = Value[int](child, "unknown")
+	gt.Expect(err).Should(g.MatchError(types.ErrNoValueFound))
+}
+
+func TestChildContext_CloseOnlyChildBeans(t *testing.T) {
+	t.Parallel()
+	gt := g.NewWithT(t)
+	parent := NewContainer()
+	err := ProvideFunc[*ServiceClose](parent, NewServiceClose)
+	gt.Expect(err).ShouldNot(g.HaveOccurred())
+	child := parent.Child()
+	err = ProvideFunc[*LifecycleService](child, func() *LifecycleService {
+		return &LifecycleService{}
+	})
+	gt.Expect(err).ShouldNot(g.HaveOccurred())
+
+	parentBean := Require[*ServiceClose](child)
+	childBean := Require[*LifecycleService](child)
+
+	err = child.Close(context.Background())
+
+	gt.Expect(err).ShouldNot(g.HaveOccurred())
+	gt.Expect(parentBean.Closed).Should(g.BeFalse())
+	gt.Expect(childBean.Events).Should(g.ContainElement("close"))
+}
@@ -0,0 +1,140 @@
+package yadi
+
+import (
+	g "github.com/onsi/gomega"
+	"github.com/xbl4de/yadi/types"
+	"testing"
+)
+
+type KafkaConfig struct {
+	Brokers []string          `yadi:"path=kafka.brokers"`
+	Ports   []int             `yadi:"path=kafka.ports"`
+	Limits  map[string]int    `yadi:"path=limits"`
+	Servers []map[string]any  `yadi:"path=servers"`
+	Tags    map[string]string `yadi:"path=kafka.tags;default='env=dev,team=core'"`
+}
+
+func NewKafkaConfig(brokers []string, limits map[string]int) *KafkaConfig {
+	return &KafkaConfig{
+		Brokers: brokers,
+		Limits:  limits,
+	}
+}
+
+func TestCollectionValue_FromStoredCollections(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	SetValue("kafka.brokers", []interface{}{"a:9092", "b:9092"})
+	SetValue("kafka.ports", []string{"9092", "9093"})
+	SetValue("limits", map[string]interface{}{"api": 10, "db": "5"})
+	SetValue("servers", []map[string]any{{"host": "a"}})
+	UseLazyContext()
+
+	config, err := GetBean[*KafkaConfig]()
+
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(config.Brokers).Should(g.Equal([]string{"a:9092", "b:9092"}))
+	g.Expect(config.Ports).Should(g.Equal([]int{9092, 9093}))
+	g.Expect(config.Limits).Should(g.Equal(map[string]int{"api": 10, "db": 5}))
+	g.Expect(config.Servers).Should(g.Equal([]map[string]any{{"host": "a"}}))
+	g.Expect(config.Tags).Should(g.Equal(map[string]string{"env": "dev", "team": "core"}))
+}
+
+func TestCollectionValue_FromCommaSeparatedStrings(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	SetValue("kafka.brokers", "a:9092, b:9092")
+	SetValue("kafka.ports", "9092,9093")
+	SetValue("limits", "api=10,db=5")
+	SetValue("servers", []map[string]any{})
+	UseLazyContext()
+
+	config, err := GetBean[*KafkaConfig]()
+
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(config.Brokers).Should(g.Equal([]string{"a:9092", "b:9092"}))
+	g.Expect(config.Ports).Should(g.Equal([]int{9092, 9093}))
+	g.Expect(config.Limits).Should(g.Equal(map[string]int{"api": 10, "db": 5}))
+}
+
+func TestCollectionValue_FromIndexedPaths(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	SetValue("kafka.brokers.0", "a:9092")
+	SetValue("kafka.brokers.1", "b:9092")
+	SetValue("kafka.ports.0", 9092)
+	SetValue("limits.api", 10)
+	SetValue("limits.db", "5")
+	SetValue("servers.0.host", "a")
+	SetValue("servers.0.port", 80)
+	SetValue("servers.1.host", "b")
+	UseLazyContext()
+
+	config, err := GetBean[*KafkaConfig]()
+
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(config.Brokers).Should(g.Equal([]string{"a:9092", "b:9092"}))
+	g.Expect(config.Ports).Should(g.Equal([]int{9092}))
+	g.Expect(config.Limits).Should(g.Equal(map[string]int{"api": 10, "db": 5}))
+	g.Expect(config.Servers).Should(g.Equal([]map[string]any{
+		{"host": "a", "port": 80},
+		{"host": "b"},
+	}))
+}
+
+func TestCollectionValue_IndexesWithGap_NotAList(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	SetValue("kafka.brokers.0", "a:9092")
+	SetValue("kafka.brokers.2", "c:9092")
+	UseLazyContext()
+
+	_, err := GetValue[[]string]("kafka.brokers")
+	g.Expect(err).Should(g.MatchError(types.ErrValueConversion))
+
+	brokers, err := GetValue[map[int]string]("kafka.brokers")
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(brokers).Should(g.Equal(map[int]string{0: "a:9092", 2: "c:9092"}))
+}
+
+func TestCollectionValue_NothingUnderPath(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	UseLazyContext()
+
+	_, err := GetValue[[]string]("kafka.brokers")
+
+	g.Expect(err).Should(g.MatchError(types.ErrNoValueFound))
+}
+
+func TestCollectionValue_FuncProviderArgs(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	SetValue("kafka.brokers.0", "a:9092")
+	SetValue("limits.api", 10)
+	SetBeanProviderFunc[*KafkaConfig](NewKafkaConfig,
+		WithValuePathAt(0, "kafka.brokers"),
+		WithValuePathAt(1, "limits"))
+	UseLazyContext()
+
+	config, err := GetBean[*KafkaConfig]()
+
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(config.Brokers).Should(g.Equal([]string{"a:9092"}))
+	g.Expect(config.Limits).Should(g.Equal(map[string]int{"api": 10}))
+}
+
+func TestCollectionValue_ChildContextShadowsParent(t *testing.T) {
+	t.Parallel()
+	gt := g.NewWithT(t)
+	parent := NewContainer()
+	parent.SetValue("limits.api", 10)
+	parent.SetValue("limits.db", 5)
+	child := parent.Child()
+	child.SetValue("limits.api", 20)
+
+	limits, err := Value[map[string]int](child, "limits")
+
+	gt.Expect(err).ShouldNot(g.HaveOccurred())
+	gt.Expect(limits).Should(g.Equal(map[string]int{"api": 20, "db": 5}))
+}
@@ -0,0 +1,240 @@
+package yadi
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/xbl4de/yadi/log"
+	"github.com/xbl4de/yadi/types"
+	"io/fs"
+	"reflect"
+)
+
+// Container is an independent DI context. Unlike the package-level API, any
+// number of containers can live in one process.
+type Container struct {
+	types.Context
+}
+
+func NewContainer() *Container {
+	return NewContainerFrom(NewLazyContext(nil))
+}
+
+func NewContainerFrom(ctx types.Context) *Container {
+	return &Container{
+		Context: ctx,
+	}
+}
+
+// DefaultContainer returns the context used by the package-level API, or nil
+// if it is not set yet.
+func DefaultContainer() *Container {
+	if globalCtx == nil {
+		return nil
+	}
+	return NewContainerFrom(globalCtx)
+}
+
+// Child is NewChild wrapped into a Container.
+func (c *Container) Child() *Container {
+	return NewContainerFrom(c.Context.NewChild())
+}
+
+func (c *Container) SetValue(path string, value interface{}) {
+	c.SetGenericValue(path, value)
+}
+
+// SetDefaultValue sets a value any source overrides.
+func (c *Container) SetDefaultValue(path string, value interface{}) {
+	c.SetLayeredValue(path, value, defaultValueOrigin)
+}
+
+// LoadValues sets the values of the sources in their layers, later sources
+// override earlier ones of the same layer.
+func (c *Container) LoadValues(sources ...types.ValueSource) error {
+	return loadValues(c, sources)
+}
+
+func (c *Container) SetActiveProfiles(profiles ...string) {
+	c.SetGenericValue(ActiveProfilesPath, profiles)
+}
+
+// LoadProfileValues loads the overlays of the file for the active profiles.
+// fsys is nil to read the files of the OS.
+func (c *Container) LoadProfileValues(fsys fs.FS, path string) error {
+	return loadProfileValues(c, path, fsys)
+}
+
+func (c *Container) Inject(valuePtr types.Bean) error {
+	return injectBean(c, valuePtr)
+}
+
+func Provide[T types.Bean](c *Container, builder func(ctx types.Context) (T, error), options ...func(provider *types.BeanProvider)) error {
+	return c.Register(newBeanProvider(builder, options))
+}
+
+func ProvideFunc[T types.Bean](c *Container, function interface{}, opts ...FuncProviderOption) error {
+	return Provide(c, funcBuilder[T](function, opts), extractProviderOptions(opts)...)
+}
+
+// ProvideAsExisting makes T resolve to the same bean as E. By default, the
+// existing bean has the same name as T; WithExistingBeanName overrides it.
+func ProvideAsExisting[T, E types.Bean](c *Container, options ...func(provider *types.BeanProvider)) error {
+	return ProvideAsExistingMany[E](c, []reflect.Type{reflect.TypeFor[T]()}, options...)
+}
+
+func ProvideAsExistingMany[E types.Bean](c *Container, interfaceTypes []reflect.Type, options ...func(provider *types.BeanProvider)) error {
+	for _, interfaceType := range interfaceTypes {
+		provider, err := newExistingBeanProvider(interfaceType, reflect.TypeFor[E](), options)
+		if err != nil {
+			return err
+		}
+		err = c.Register(provider)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func Get[T types.Bean](ctx types.Context) (T, error) {
+	var zeroValue T
+	typ := reflect.TypeFor[T]()
+	bean, err := ctx.Get(typ)
+	if err != nil {
+		return zeroValue, err
+	}
+	return castBean[T](bean)
+}
+
+func GetNamed[T types.Bean](ctx types.Context, name string) (T, error) {
+	var zeroValue T
+	typ := reflect.TypeFor[T]()
+	bean, err := ctx.GetNamed(typ, name)
+	if err != nil {
+		return zeroValue, err
+	}
+	return castBean[T](bean)
+}
+
+// GetCtx resolves the bean with goCtx, which is passed to scopes such as
+// RequestScope.
+func GetCtx[T types.Bean](ctx types.Context, goCtx context.Context) (T, error) {
+	var zeroValue T
+	typ := reflect.TypeFor[T]()
+	bean, err := ctx.GetWithContext(goCtx, typ)
+	if err != nil {
+		return zeroValue, err
+	}
+	return castBean[T](bean)
+}
+
+func GetNamedCtx[T types.Bean](ctx types.Context, goCtx context.Context, name string) (T, error) {
+	var zeroValue T
+	typ := reflect.TypeFor[T]()
+	bean, err := ctx.GetNamedWithContext(goCtx, typ, name)
+	if err != nil {
+		return zeroValue, err
+	}
+	return castBean[T](bean)
+}
+
+func Require[T types.Bean](ctx types.Context) T {
+	bean, err := Get[T](ctx)
+	if err != nil {
+		log.Log("%+v", err)
+		panic(err)
+	}
+	return bean
+}
+
+func RequireNamed[T types.Bean](ctx types.Context, name string) T {
+	bean, err := GetNamed[T](ctx, name)
+	if err != nil {
+		log.Log("%+v", err)
+		panic(err)
+	}
+	return bean
+}
+
+func Lazy[T types.Bean](ctx types.Context) types.LazyBean[T] {
+	return func() T {
+		return Require[T](ctx)
+	}
+}
+
+func Value[T interface{}](ctx types.Context, path string) (T, error) {
+	var zeroValue T
+	val, err := getTypedValue(ctx, path, reflect.TypeFor[T]())
+	if err != nil {
+		return zeroValue, errors.WithMessagef(err, "Failed to get value by path: %s", path)
+	}
+	return val.(T), nil
+}
+
+func castBean[T types.Bean](bean types.Bean) (T, error) {
+	casted, ok := bean.(T)
+	if !ok {
+		var zeroValue T
+		return zeroValue, errors.Errorf("Failed to cast bean to type %s: actual type is %s",
+			reflect.TypeFor[T]().String(), reflect.TypeOf(bean).String())
+	}
+	return casted, nil
+}
+
+func newBeanProvider[T types.Bean](builder func(ctx types.Context) (T, error), options []func(provider *types.BeanProvider)) *types.BeanProvider {
+	provider := &types.BeanProvider{
+		Builder: func(ctx types.Context) (types.Bean, error) {
+			return builder(ctx)
+		},
+		BeanType:      reflect.TypeFor[T](),
+		HoldByContext: true,
+	}
+	for _, option := range options {
+		option(provider)
+	}
+	return provider
+}
+
+func newExistingBeanProvider(
+	interfaceType reflect.Type,
+	existingType reflect.Type,
+	options []func(provider *types.BeanProvider),
+) (*types.BeanProvider, error) {
+	if interfaceType.Kind() != reflect.Interface {
+		return nil, errors.Wrapf(types.ErrIncompatibleBeanType, "%s is not an interface", interfaceType.String())
+	}
+	if !existingType.Implements(interfaceType) {
+		return nil, errors.Wrapf(types.ErrIncompatibleBeanType, "%s does not implement %s",
+			existingType.String(), interfaceType.String())
+	}
+	provider := &types.BeanProvider{
+		BeanType:        interfaceType,
+		UseExistingBean: existingType,
+	}
+	for _, option := range options {
+		option(provider)
+	}
+	if provider.ExistingBeanName == "" {
+		provider.ExistingBeanName = provider.BeanName
+	}
+	provider.HoldByContext = false
+	return provider, nil
+}
+
+func funcBuilder[T types.Bean](function interface{}, opts []FuncProviderOption) func(ctx types.Context) (T, error) {
+	return func(ctx types.Context) (T, error) {
+		cfg := NewFuncProviderConfig()
+		for _, opt := range opts {
+			opt(cfg)
+		}
+		return providerFromFuncE[T](ctx, function, cfg)
+	}
+}
+
+func injectBean(ctx types.Context, valuePtr types.Bean) error {
+	err := injectToPtr(ctx, reflect.ValueOf(valuePtr))
+	if err != nil {
+		return err
+	}
+	return postConstruct(valuePtr, nil)
+}
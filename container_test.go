@@ -0,0 +1,166 @@
+package yadi
+
+import (
+	"context"
+	g "github.com/onsi/gomega"
+	"github.com/xbl4de/yadi/types"
+	"testing"
+)
+
+func newTestContainer() *Container {
+	c := NewContainer()
+	c.SetValue("serviceA.name", ServiceAName)
+	c.SetValue("serviceE.description", ServiceEDescription)
+	c.SetValue("serviceF.count", ServiceFCount)
+	return c
+}
+
+func TestContainer_Get_AutoBuild(t *testing.T) {
+	t.Parallel()
+	gt := g.NewWithT(t)
+	c := newTestContainer()
+
+	serviceA, err := Get[*ServiceA](c)
+
+	gt.Expect(err).ShouldNot(g.HaveOccurred())
+	gt.Expect(serviceA.Name).Should(g.Equal(ServiceAName))
+	gt.Expect(serviceA.ServiceE.Description).Should(g.Equal(ServiceEDescription))
+}
+
+func TestContainer_Provide_ResolveFromProviderContext(t *testing.T) {
+	t.Parallel()
+	gt := g.NewWithT(t)
+	c := newTestContainer()
+
+	err := Provide(c, func(ctx types.Context) (*ServiceA, error) {
+		e, err := Get[*ServiceE](ctx)
+		if err != nil {
+			return nil, err
+		}
+		return NewServiceA("provided", e), nil
+	})
+	gt.Expect(err).ShouldNot(g.HaveOccurred())
+
+	serviceA, err := Get[*ServiceA](c)
+
+	gt.Expect(err).ShouldNot(g.HaveOccurred())
+	gt.Expect(serviceA.Name).Should(g.Equal("provided"))
+	gt.Expect(serviceA.ServiceE.Description).Should(g.Equal(ServiceEDescription))
+}
+
+func TestContainer_ProvideFunc_Named(t *testing.T) {
+	t.Parallel()
+	gt := g.NewWithT(t)
+	c := newTestContainer()
+
+	err := ProvideFunc[*ServiceE](c, NewServiceE,
+		WithDefaultValueAt(0, "named"),
+		WithFuncProviderBeanName("serviceE"))
+	gt.Expect(err).ShouldNot(g.HaveOccurred())
+
+	named, err := GetNamed[*ServiceE](c, "serviceE")
+	gt.Expect(err).ShouldNot(g.HaveOccurred())
+	gt.Expect(named.Description).Should(g.Equal("named"))
+
+	_, err = GetNamed[*ServiceE](c, "unknown")
+	gt.Expect(err).Should(g.MatchError(types.ErrNoBeanProvider))
+}
+
+func TestContainer_IndependentContainers(t *testing.T) {
+	t.Parallel()
+	gt := g.NewWithT(t)
+	first := NewContainer()
+	second := NewContainer()
+	first.SetValue("serviceE.description", "first")
+	second.SetValue("serviceE.description", "second")
+
+	firstE := Require[*ServiceE](first)
+	secondE := Require[*ServiceE](second)
+
+	gt.Expect(firstE.Description).Should(g.Equal("first"))
+	gt.Expect(secondE.Description).Should(g.Equal("second"))
+}
+
+func TestContainer_Value(t *testing.T) {
+	t.Parallel()
+	gt := g.NewWithT(t)
+	c := newTestContainer()
+
+	count, err := Value[int](c, "serviceF.count")
+	gt.Expect(err).ShouldNot(g.HaveOccurred())
+	gt.Expect(count).Should(g.Equal(ServiceFCount))
+
+	_, err = Value[int](c, "unknown")
+	gt.Expect(err).Should(g.MatchError(types.ErrNoValueFound))
+}
+
+func TestContainer_Inject(t *testing.T) {
+	t.Parallel()
+	gt := g.NewWithT(t)
+	c := newTestContainer()
+
+	serviceA := ServiceA{}
+	err := c.Inject(&serviceA)
+
+	gt.Expect(err).ShouldNot(g.HaveOccurred())
+	gt.Expect(serviceA.Name).Should(g.Equal(ServiceAName))
+	gt.Expect(serviceA.ServiceF.Count).Should(g.Equal(ServiceFCount))
+}
+
+func TestContainer_Lazy(t *testing.T) {
+	t.Parallel()
+	gt := g.NewWithT(t)
+	c := newTestContainer()
+
+	lazyE := Lazy[*ServiceE](c)
+
+	gt.Expect(lazyE().Description).Should(g.Equal(ServiceEDescription))
+}
+
+func TestContainer_Close(t *testing.T) {
+	t.Parallel()
+	gt := g.NewWithT(t)
+	c := NewContainer()
+	err := ProvideFunc[*ServiceClose](c, NewServiceClose)
+	gt.Expect(err).ShouldNot(g.HaveOccurred())
+	serviceClose := Require[*ServiceClose](c)
+
+	err = c.Close(context.Background())
+
+	gt.Expect(err).ShouldNot(g.HaveOccurred())
+	gt.Expect(serviceClose.Closed).Should(g.BeTrue())
+}
+
+func TestContainer_CloseTwice(t *testing.T) {
+	t.Parallel()
+	gt := g.NewWithT(t)
+	c := NewContainer()
+	destroyed := 0
+	err := Provide[*ServiceClose](c, func(ctx types.Context) (*ServiceClose, error) {
+		return &ServiceClose{}, nil
+	}, WithDestroyFunc(func(ctx context.Context, bean *ServiceClose) error {
+		destroyed++
+		return nil
+	}))
+	gt.Expect(err).ShouldNot(g.HaveOccurred())
+	closed := Require[*ServiceClose](c)
+
+	gt.Expect(c.Close(context.Background())).Should(g.Succeed())
+	gt.Expect(c.Close(context.Background())).Should(g.Succeed())
+
+	gt.Expect(destroyed).Should(g.Equal(1))
+	gt.Expect(Require[*ServiceClose](c)).ShouldNot(g.BeIdenticalTo(closed))
+}
+
+func TestDefaultContainer_WrapsGlobalContext(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	g.Expect(DefaultContainer()).Should(g.BeNil())
+
+	ProvideDefaultValues()
+	UseLazyContext()
+
+	serviceE, err := Get[*ServiceE](DefaultContainer())
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(serviceE).Should(g.BeIdenticalTo(RequireBean[*ServiceE]()))
+}
@@ -0,0 +1,331 @@
+package yadi
+
+import (
+	"encoding"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/xbl4de/yadi/types"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	durationType        = reflect.TypeFor[time.Duration]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+type converter struct {
+	from    reflect.Type
+	convert func(value reflect.Value) (reflect.Value, error)
+}
+
+var (
+	convertersMu sync.RWMutex
+	converters   = map[reflect.Type][]converter{}
+)
+
+// RegisterConverter adds a conversion used when a value of type From is read
+// as To. From may be an interface implemented by the stored values.
+func RegisterConverter[From, To any](convert func(From) (To, error)) {
+	to := reflect.TypeFor[To]()
+	c := converter{
+		from: reflect.TypeFor[From](),
+		convert: func(value reflect.Value) (reflect.Value, error) {
+			converted, err := convert(value.Interface().(From))
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			return reflect.ValueOf(&converted).Elem(), nil
+		},
+	}
+	convertersMu.Lock()
+	defer convertersMu.Unlock()
+	converters[to] = append(converters[to], c)
+}
+
+func findConverter(from reflect.Type, to reflect.Type) (converter, bool) {
+	convertersMu.RLock()
+	defer convertersMu.RUnlock()
+	candidates := converters[to]
+	for i := len(candidates) - 1; i >= 0; i-- {
+		if candidates[i].from == from {
+			return candidates[i], true
+		}
+	}
+	for i := len(candidates) - 1; i >= 0; i-- {
+		if candidates[i].from.Kind() == reflect.Interface && from.Implements(candidates[i].from) {
+			return candidates[i], true
+		}
+	}
+	return converter{}, false
+}
+
+// convertValue converts a stored value to the requested type. Strings are
+// parsed, numbers are converted if they fit into the target type.
+func convertValue(value interface{}, typ reflect.Type) (interface{}, error) {
+	if value == nil {
+		return nil, conversionError(value, typ, nil)
+	}
+	converted, err := convertReflectValue(reflect.ValueOf(value), typ)
+	if err != nil {
+		return nil, err
+	}
+	return converted.Interface(), nil
+}
+
+func convertReflectValue(value reflect.Value, typ reflect.Type) (reflect.Value, error) {
+	if value.Kind() == reflect.Interface {
+		value = value.Elem()
+	}
+	if !value.IsValid() {
+		return reflect.Value{}, conversionError(nil, typ, nil)
+	}
+	if value.Type().AssignableTo(typ) {
+		return value, nil
+	}
+	if c, ok := findConverter(value.Type(), typ); ok {
+		converted, err := c.convert(value)
+		if err != nil {
+			return reflect.Value{}, conversionError(value.Interface(), typ, err)
+		}
+		return converted, nil
+	}
+	switch {
+	case value.Kind() == reflect.String:
+		return convertString(value.String(), typ)
+	case isNumberKind(value.Kind()) && isNumberKind(typ.Kind()):
+		return convertNumber(value, typ)
+	case isListKind(value.Kind()) && isListKind(typ.Kind()):
+		return convertList(value, typ)
+	case value.Kind() == reflect.Map && typ.Kind() == reflect.Map:
+		return convertMap(value, typ)
+	case value.Kind() == typ.Kind() && value.Type().ConvertibleTo(typ):
+		return value.Convert(typ), nil
+	}
+	return reflect.Value{}, conversionError(value.Interface(), typ, nil)
+}
+
+func convertString(value string, typ reflect.Type) (reflect.Value, error) {
+	if typ.Kind() == reflect.Ptr && typ.Implements(textUnmarshalerType) {
+		result := reflect.New(typ.Elem())
+		err := result.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
+		if err != nil {
+			return reflect.Value{}, conversionError(value, typ, err)
+		}
+		return result, nil
+	}
+	if reflect.PointerTo(typ).Implements(textUnmarshalerType) {
+		result := reflect.New(typ)
+		err := result.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
+		if err != nil {
+			return reflect.Value{}, conversionError(value, typ, err)
+		}
+		return result.Elem(), nil
+	}
+	result := reflect.New(typ).Elem()
+	switch typ.Kind() {
+	case reflect.String:
+		result.SetString(value)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return reflect.Value{}, conversionError(value, typ, err)
+		}
+		result.SetBool(parsed)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		parsed, err := parseInt(value, typ)
+		if err != nil {
+			return reflect.Value{}, conversionError(value, typ, err)
+		}
+		result.SetInt(parsed)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		parsed, err := strconv.ParseUint(value, 10, typ.Bits())
+		if err != nil {
+			return reflect.Value{}, conversionError(value, typ, err)
+		}
+		result.SetUint(parsed)
+	case reflect.Float32, reflect.Float64:
+		parsed, err := strconv.ParseFloat(value, typ.Bits())
+		if err != nil {
+			return reflect.Value{}, conversionError(value, typ, err)
+		}
+		result.SetFloat(parsed)
+	case reflect.Slice:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			result.SetBytes([]byte(value))
+			break
+		}
+		items := splitList(value)
+		result = reflect.MakeSlice(typ, len(items), len(items))
+		for i, item := range items {
+			converted, err := convertString(item, typ.Elem())
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("item %d: %w", i, err)
+			}
+			result.Index(i).Set(converted)
+		}
+	case reflect.Map:
+		result = reflect.MakeMap(typ)
+		for _, item := range splitList(value) {
+			itemKey, itemValue, ok := strings.Cut(item, "=")
+			if !ok {
+				return reflect.Value{}, conversionError(value, typ, errors.Errorf("expected key=value, got %s", item))
+			}
+			convertedKey, err := convertString(strings.TrimSpace(itemKey), typ.Key())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			convertedValue, err := convertString(strings.TrimSpace(itemValue), typ.Elem())
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("key %s: %w", itemKey, err)
+			}
+			result.SetMapIndex(convertedKey, convertedValue)
+		}
+	default:
+		return reflect.Value{}, conversionError(value, typ, nil)
+	}
+	return result, nil
+}
+
+func convertList(value reflect.Value, typ reflect.Type) (reflect.Value, error) {
+	length := value.Len()
+	var result reflect.Value
+	if typ.Kind() == reflect.Array {
+		if length != typ.Len() {
+			return reflect.Value{}, conversionError(value.Interface(), typ,
+				errors.Errorf("expected %d items, got %d", typ.Len(), length))
+		}
+		result = reflect.New(typ).Elem()
+	} else {
+		result = reflect.MakeSlice(typ, length, length)
+	}
+	for i := 0; i < length; i++ {
+		converted, err := convertReflectValue(value.Index(i), typ.Elem())
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("item %d: %w", i, err)
+		}
+		result.Index(i).Set(converted)
+	}
+	return result, nil
+}
+
+func convertMap(value reflect.Value, typ reflect.Type) (reflect.Value, error) {
+	result := reflect.MakeMapWithSize(typ, value.Len())
+	iter := value.MapRange()
+	for iter.Next() {
+		convertedKey, err := convertReflectValue(iter.Key(), typ.Key())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		convertedValue, err := convertReflectValue(iter.Value(), typ.Elem())
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("key %v: %w", iter.Key().Interface(), err)
+		}
+		result.SetMapIndex(convertedKey, convertedValue)
+	}
+	return result, nil
+}
+
+// isTextType reports whether values of typ can be parsed from text, so a
+// struct like time.Time is read as a value rather than built as a bean.
+func isTextType(typ reflect.Type) bool {
+	return typ.Implements(textUnmarshalerType) || reflect.PointerTo(typ).Implements(textUnmarshalerType)
+}
+
+func parseInt(value string, typ reflect.Type) (int64, error) {
+	if typ == durationType {
+		duration, err := time.ParseDuration(value)
+		return int64(duration), err
+	}
+	return strconv.ParseInt(value, 10, typ.Bits())
+}
+
+func splitList(value string) []string {
+	if strings.TrimSpace(value) == "" {
+		return nil
+	}
+	items := strings.Split(value, ",")
+	for i := range items {
+		items[i] = strings.TrimSpace(items[i])
+	}
+	return items
+}
+
+func convertNumber(value reflect.Value, typ reflect.Type) (reflect.Value, error) {
+	result := reflect.New(typ).Elem()
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		converted, ok := toInt64(value)
+		if !ok || result.OverflowInt(converted) {
+			return reflect.Value{}, conversionError(value.Interface(), typ, strconv.ErrRange)
+		}
+		result.SetInt(converted)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		converted, ok := toUint64(value)
+		if !ok || result.OverflowUint(converted) {
+			return reflect.Value{}, conversionError(value.Interface(), typ, strconv.ErrRange)
+		}
+		result.SetUint(converted)
+	default:
+		converted := toFloat64(value)
+		if result.OverflowFloat(converted) {
+			return reflect.Value{}, conversionError(value.Interface(), typ, strconv.ErrRange)
+		}
+		result.SetFloat(converted)
+	}
+	return result, nil
+}
+
+func toInt64(value reflect.Value) (int64, bool) {
+	switch {
+	case value.CanInt():
+		return value.Int(), true
+	case value.CanUint():
+		return int64(value.Uint()), value.Uint() <= math.MaxInt64
+	default:
+		f := value.Float()
+		return int64(f), f == math.Trunc(f) && f >= math.MinInt64 && f < math.MaxInt64
+	}
+}
+
+func toUint64(value reflect.Value) (uint64, bool) {
+	switch {
+	case value.CanInt():
+		return uint64(value.Int()), value.Int() >= 0
+	case value.CanUint():
+		return value.Uint(), true
+	default:
+		f := value.Float()
+		return uint64(f), f == math.Trunc(f) && f >= 0 && f < math.MaxUint64
+	}
+}
+
+func toFloat64(value reflect.Value) float64 {
+	switch {
+	case value.CanInt():
+		return float64(value.Int())
+	case value.CanUint():
+		return float64(value.Uint())
+	default:
+		return value.Float()
+	}
+}
+
+func isListKind(kind reflect.Kind) bool {
+	return kind == reflect.Slice || kind == reflect.Array
+}
+
+func isNumberKind(kind reflect.Kind) bool {
+	return kind >= reflect.Int && kind <= reflect.Float64
+}
+
+func conversionError(value interface{}, typ reflect.Type, cause error) error {
+	if cause != nil {
+		return fmt.Errorf("%w: cannot convert %#v to %s: %w", types.ErrValueConversion, value, typ.String(), cause)
+	}
+	return fmt.Errorf("%w: cannot convert %#v to %s", types.ErrValueConversion, value, typ.String())
+}
@@ -0,0 +1,166 @@
+package yadi
+
+import (
+	g "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"github.com/xbl4de/yadi/types"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+)
+
+type LogLevel int
+
+func (l *LogLevel) UnmarshalText(text []byte) error {
+	switch strings.ToLower(string(text)) {
+	case "debug":
+		*l = 0
+	case "info":
+		*l = 1
+	default:
+		return errors.Errorf("unknown log level %s", text)
+	}
+	return nil
+}
+
+type Money struct {
+	Cents int64
+}
+
+type ConvertedConfig struct {
+	Port    int           `yadi:"path=converted.port"`
+	Size    int64         `yadi:"path=converted.size"`
+	Enabled bool          `yadi:"path=converted.enabled"`
+	Ratio   float32       `yadi:"path=converted.ratio"`
+	Timeout time.Duration `yadi:"path=converted.timeout"`
+	Level   LogLevel      `yadi:"path=converted.level"`
+	Addr    netip.Addr    `yadi:"path=converted.addr"`
+}
+
+func TestConvert_FieldInjection(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	SetValue("converted.port", "8080")
+	SetValue("converted.size", 42)
+	SetValue("converted.enabled", "true")
+	SetValue("converted.ratio", 0.25)
+	SetValue("converted.timeout", "2s")
+	SetValue("converted.level", "info")
+	SetValue("converted.addr", "127.0.0.1")
+	UseLazyContext()
+
+	config, err := GetBean[*ConvertedConfig]()
+
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(config.Port).Should(g.Equal(8080))
+	g.Expect(config.Size).Should(g.Equal(int64(42)))
+	g.Expect(config.Enabled).Should(g.BeTrue())
+	g.Expect(config.Ratio).Should(g.Equal(float32(0.25)))
+	g.Expect(config.Timeout).Should(g.Equal(2 * time.Second))
+	g.Expect(config.Level).Should(g.Equal(LogLevel(1)))
+	g.Expect(config.Addr).Should(g.Equal(netip.MustParseAddr("127.0.0.1")))
+}
+
+func TestConvert_FieldInjection_InvalidValue(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	SetValue("converted.port", "http")
+	UseLazyContext()
+
+	_, err := GetBean[*ConvertedConfig]()
+
+	g.Expect(err).Should(g.MatchError(types.ErrValueConversion))
+	g.Expect(err.Error()).Should(g.ContainSubstring("Port"))
+}
+
+func TestConvert_GetValue_Numbers(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	SetValue("number", 300)
+	SetValue("negative", -1)
+	SetValue("fraction", 1.5)
+	UseLazyContext()
+
+	wide, err := GetValue[int64]("number")
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(wide).Should(g.Equal(int64(300)))
+
+	narrow, err := GetValue[int16]("number")
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(narrow).Should(g.Equal(int16(300)))
+
+	_, err = GetValue[int8]("number")
+	g.Expect(err).Should(g.MatchError(types.ErrValueConversion))
+
+	_, err = GetValue[uint]("negative")
+	g.Expect(err).Should(g.MatchError(types.ErrValueConversion))
+
+	_, err = GetValue[int]("fraction")
+	g.Expect(err).Should(g.MatchError(types.ErrValueConversion))
+
+	f, err := GetValue[float64]("number")
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(f).Should(g.Equal(300.0))
+}
+
+func TestConvert_GetValue_Strings(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	SetValue("enabled", "false")
+	SetValue("timeout", "1h")
+	SetValue("count", "12")
+	UseLazyContext()
+
+	enabled, err := GetValue[bool]("enabled")
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(enabled).Should(g.BeFalse())
+
+	timeout, err := GetValue[time.Duration]("timeout")
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(timeout).Should(g.Equal(time.Hour))
+
+	count, err := GetValue[uint8]("count")
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(count).Should(g.Equal(uint8(12)))
+}
+
+func TestConvert_RegisterConverter(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	RegisterConverter(func(value string) (Money, error) {
+		amount, err := time.ParseDuration(value + "ns")
+		if err != nil {
+			return Money{}, err
+		}
+		return Money{Cents: int64(amount)}, nil
+	})
+	RegisterConverter(func(value int) (Money, error) {
+		return Money{Cents: int64(value) * 100}, nil
+	})
+	SetValue("price.text", "250")
+	SetValue("price.units", 3)
+	UseLazyContext()
+
+	text, err := GetValue[Money]("price.text")
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(text.Cents).Should(g.Equal(int64(250)))
+
+	units, err := GetValue[Money]("price.units")
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(units.Cents).Should(g.Equal(int64(300)))
+}
+
+func TestConvert_FuncProviderArgs(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	SetValue("converted.age", "33")
+	ProvideDefaultValues()
+	UseLazyContext()
+
+	SetBeanProviderFunc[*ServiceB](NewServiceB, WithValuePathAt(0, "converted.age"))
+	serviceB, err := GetBean[*ServiceB]()
+
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(serviceB.Age).Should(g.Equal(33))
+}
@@ -0,0 +1,98 @@
+package yadi
+
+import (
+	g "github.com/onsi/gomega"
+	"github.com/xbl4de/yadi/types"
+	"testing"
+	"time"
+)
+
+type ServerConfig struct {
+	Host     string        `yadi:"path=server.host;default=localhost"`
+	Port     int           `yadi:"path=server.port;default=8080"`
+	Debug    bool          `yadi:"path=server.debug;default=true"`
+	Ratio    float64       `yadi:"path=server.ratio;default=0.5"`
+	Timeout  time.Duration `yadi:"path=server.timeout;default=1m30s"`
+	Origins  []string      `yadi:"path=server.origins;default='a.com, b.com'"`
+	Ports    []uint16      `yadi:"path=server.ports;default='80,443'"`
+	Greeting string        `yadi:"path=server.greeting;default='hello; world'"`
+	Motto    string        `yadi:"path=server.motto;default=it\\'s a\\;b"`
+}
+
+type BrokenDefaultConfig struct {
+	Port int `yadi:"path=server.port;default=abc"`
+}
+
+type OverflowDefaultConfig struct {
+	Port int8 `yadi:"path=server.port;default=300"`
+}
+
+func TestDefaultValue_ValuesMissing_DefaultsInjected(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	UseLazyContext()
+
+	config, err := GetBean[*ServerConfig]()
+
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(config.Host).Should(g.Equal("localhost"))
+	g.Expect(config.Port).Should(g.Equal(8080))
+	g.Expect(config.Debug).Should(g.BeTrue())
+	g.Expect(config.Ratio).Should(g.Equal(0.5))
+	g.Expect(config.Timeout).Should(g.Equal(90 * time.Second))
+	g.Expect(config.Origins).Should(g.Equal([]string{"a.com", "b.com"}))
+	g.Expect(config.Ports).Should(g.Equal([]uint16{80, 443}))
+	g.Expect(config.Greeting).Should(g.Equal("hello; world"))
+	g.Expect(config.Motto).Should(g.Equal("it's a;b"))
+}
+
+func TestDefaultValue_ValuePresent_ValueInjected(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	SetValue("server.host", "example.com")
+	SetValue("server.port", 9090)
+	UseLazyContext()
+
+	config, err := GetBean[*ServerConfig]()
+
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(config.Host).Should(g.Equal("example.com"))
+	g.Expect(config.Port).Should(g.Equal(9090))
+}
+
+func TestDefaultValue_OptionalWrapper_DefaultInjected(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	UseLazyContext()
+
+	var config struct {
+		Port types.Optional[int] `yadi:"path=server.port;default=8080"`
+	}
+	err := Inject(&config)
+
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	port, ok := config.Port.Get()
+	g.Expect(ok).Should(g.BeTrue())
+	g.Expect(port).Should(g.Equal(8080))
+}
+
+func TestDefaultValue_InvalidDefault(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	UseLazyContext()
+
+	_, err := GetBean[*BrokenDefaultConfig]()
+
+	g.Expect(err).Should(g.HaveOccurred())
+	g.Expect(err.Error()).Should(g.ContainSubstring("Port"))
+}
+
+func TestDefaultValue_Overflow(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	UseLazyContext()
+
+	_, err := GetBean[*OverflowDefaultConfig]()
+
+	g.Expect(err).Should(g.HaveOccurred())
+}
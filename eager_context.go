@@ -0,0 +1,46 @@
+package yadi
+
+import (
+	"context"
+	stdErrors "errors"
+	"fmt"
+	"github.com/xbl4de/yadi/types"
+)
+
+// EagerContext builds every registered provider on creation, so
+// misconfigured beans are reported at startup instead of at the first lookup.
+// Providers marked with WithLazyInit, scoped providers and providers
+// registered after creation are built on demand, as in LazyContext.
+type EagerContext struct {
+	*LazyContext
+}
+
+func NewEagerContext(updates []func(ctx types.Context) error) (*EagerContext, error) {
+	ctx := &EagerContext{
+		LazyContext: NewLazyContext(updates),
+	}
+	err := ctx.initProviders()
+	if err != nil {
+		_ = ctx.Close(context.Background())
+		return nil, err
+	}
+	return ctx, nil
+}
+
+func (ctx *EagerContext) initProviders() error {
+	var errs []error
+	for _, provider := range ctx.registeredProviders() {
+		if provider.Lazy || provider.Scope != nil {
+			continue
+		}
+		key := keyFromProvider(provider)
+		_, err := ctx.get(newResolution(ctx.LazyContext), key, key.Name == "")
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%w:\n%w", types.ErrEagerInit, stdErrors.Join(errs...))
+	}
+	return nil
+}
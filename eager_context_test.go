@@ -0,0 +1,110 @@
+package yadi
+
+import (
+	g "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"github.com/xbl4de/yadi/types"
+	"testing"
+)
+
+func TestUseEagerContext_BuildsProvidersOnCreation(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	ProvideDefaultValues()
+
+	builtE := false
+	builtB := false
+	SetBeanProvider[*ServiceE](func(ctx types.Context) (*ServiceE, error) {
+		builtE = true
+		return NewServiceE("eager"), nil
+	})
+	SetBeanProviderFunc[*ServiceB](func(f *ServiceF, h *ServiceH) *ServiceB {
+		builtB = true
+		return NewServiceB(1, f, h)
+	})
+
+	err := UseEagerContext()
+
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(builtE).Should(g.BeTrue())
+	g.Expect(builtB).Should(g.BeTrue())
+
+	serviceB, err := GetBean[*ServiceB]()
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(serviceB.ServiceF.Count).Should(g.Equal(ServiceFCount))
+}
+
+func TestUseEagerContext_AggregatesErrors(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+
+	errServiceE := errors.New("errServiceE")
+	errServiceF := errors.New("errServiceF")
+	SetBeanProvider[*ServiceE](func(ctx types.Context) (*ServiceE, error) {
+		return nil, errServiceE
+	})
+	SetBeanProvider[*ServiceF](func(ctx types.Context) (*ServiceF, error) {
+		return nil, errServiceF
+	})
+	SetBeanProvider[*ServiceG](func(ctx types.Context) (*ServiceG, error) {
+		return NewServiceG(true), nil
+	})
+
+	err := UseEagerContext()
+
+	g.Expect(err).Should(g.MatchError(types.ErrEagerInit))
+	g.Expect(err).Should(g.MatchError(errServiceE))
+	g.Expect(err).Should(g.MatchError(errServiceF))
+	g.Expect(err.Error()).Should(g.ContainSubstring("*yadi.ServiceE"))
+	g.Expect(err.Error()).Should(g.ContainSubstring("*yadi.ServiceF"))
+	g.Expect(err.Error()).ShouldNot(g.ContainSubstring("*yadi.ServiceG"))
+	g.Expect(getGlobalCtx()).Should(g.BeNil())
+}
+
+func TestUseEagerContext_BuildsUnnamedProviderBesidePrimary(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+
+	errServiceE := errors.New("errServiceE")
+	SetBeanProvider[*ServiceE](func(ctx types.Context) (*ServiceE, error) {
+		return nil, errServiceE
+	})
+	SetBeanProvider[*ServiceE](func(ctx types.Context) (*ServiceE, error) {
+		return NewServiceE("primary"), nil
+	}, WithBeanName("primary"), WithPrimary())
+
+	err := UseEagerContext()
+
+	g.Expect(err).Should(g.MatchError(errServiceE))
+}
+
+func TestUseEagerContext_WithLazyInit_ShouldSkipProvider(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+
+	built := false
+	SetBeanProvider[*ServiceE](func(ctx types.Context) (*ServiceE, error) {
+		built = true
+		return NewServiceE("lazy"), nil
+	}, WithLazyInit())
+
+	err := UseEagerContext()
+
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(built).Should(g.BeFalse())
+
+	serviceE, err := GetBean[*ServiceE]()
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(serviceE.Description).Should(g.Equal("lazy"))
+	g.Expect(built).Should(g.BeTrue())
+}
+
+func TestUseEagerContext_UseTwice_ShouldPanic(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+
+	g.Expect(func() {
+		_ = UseEagerContext()
+		_ = UseEagerContext()
+	}).Should(g.Panic())
+}
@@ -0,0 +1,133 @@
+package yadi
+
+import (
+	"github.com/xbl4de/yadi/types"
+	"os"
+	"strings"
+)
+
+// EnvCase is the case of environment variable names.
+type EnvCase int
+
+const (
+	EnvUpperCase EnvCase = iota
+	EnvLowerCase
+	EnvKeepCase
+)
+
+// EnvSource resolves values from environment variables. A path maps to the
+// variable named by the prefix and the path segments joined with the
+// separator, e.g. db.host -> APP_DB_HOST for the prefix APP.
+type EnvSource struct {
+	prefix    string
+	separator string
+	envCase   EnvCase
+	lookupEnv func(name string) (string, bool)
+	environ   func() []string
+}
+
+type EnvSourceOption func(source *EnvSource)
+
+func NewEnvSource(options ...EnvSourceOption) *EnvSource {
+	source := &EnvSource{
+		separator: "_",
+		envCase:   EnvUpperCase,
+		lookupEnv: os.LookupEnv,
+		environ:   os.Environ,
+	}
+	for _, option := range options {
+		option(source)
+	}
+	return source
+}
+
+func WithEnvPrefix(prefix string) EnvSourceOption {
+	return func(source *EnvSource) {
+		source.prefix = prefix
+	}
+}
+
+func WithEnvSeparator(separator string) EnvSourceOption {
+	return func(source *EnvSource) {
+		source.separator = separator
+	}
+}
+
+func WithEnvCase(envCase EnvCase) EnvSourceOption {
+	return func(source *EnvSource) {
+		source.envCase = envCase
+	}
+}
+
+func (s *EnvSource) Name() string {
+	return "env"
+}
+
+func (s *EnvSource) Layer() types.ValueLayer {
+	return types.EnvLayer
+}
+
+func (s *EnvSource) Locate(path string) string {
+	return s.VariableName(path)
+}
+
+// VariableName returns the environment variable the path is read from.
+func (s *EnvSource) VariableName(path string) string {
+	name := strings.ReplaceAll(path, ".", s.separator)
+	if s.prefix != "" {
+		name = s.prefix + s.separator + name
+	}
+	switch s.envCase {
+	case EnvUpperCase:
+		return strings.ToUpper(name)
+	case EnvLowerCase:
+		return strings.ToLower(name)
+	default:
+		return name
+	}
+}
+
+func (s *EnvSource) Lookup(path string) (interface{}, bool) {
+	value, ok := s.lookupEnv(s.VariableName(path))
+	if !ok {
+		return nil, false
+	}
+	return value, true
+}
+
+// Load lists the variables with the prefix of the source. Their paths are
+// lowercased unless the source keeps the case.
+func (s *EnvSource) Load() (map[string]interface{}, error) {
+	values := make(map[string]interface{})
+	prefix := ""
+	if s.prefix != "" {
+		prefix = s.VariableName("")
+	}
+	for _, variable := range s.environ() {
+		name, value, _ := strings.Cut(variable, "=")
+		if !s.matchesCase(name) {
+			continue
+		}
+		relativeName, ok := strings.CutPrefix(name, prefix)
+		if !ok || relativeName == "" {
+			continue
+		}
+		path := strings.ReplaceAll(relativeName, s.separator, ".")
+		if s.envCase != EnvKeepCase {
+			path = strings.ToLower(path)
+		}
+		values[path] = value
+	}
+	return values, nil
+}
+
+func (s *EnvSource) matchesCase(name string) bool {
+	switch s.envCase {
+	case EnvUpperCase:
+		return name == strings.ToUpper(name)
+	case EnvLowerCase:
+		return name == strings.ToLower(name)
+	default:
+		return true
+	}
+}
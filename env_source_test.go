@@ -0,0 +1,205 @@
+package yadi
+
+import (
+	g "github.com/onsi/gomega"
+	"github.com/xbl4de/yadi/types"
+	"strings"
+	"testing"
+)
+
+type EnvConfig struct {
+	Host     string   `yadi:"path=db.host"`
+	Port     int      `yadi:"path=db.port;default=5432"`
+	URL      string   `yadi:"env=DATABASE_URL;path=db.url"`
+	Token    string   `yadi:"env=API_TOKEN;default=none"`
+	Replicas []string `yadi:"path=db.replicas;optional"`
+}
+
+func TestEnvSource_VariableName(t *testing.T) {
+	t.Parallel()
+	gt := g.NewWithT(t)
+
+	gt.Expect(NewEnvSource().VariableName("db.host")).Should(g.Equal("DB_HOST"))
+	gt.Expect(NewEnvSource(WithEnvPrefix("app")).VariableName("db.host")).Should(g.Equal("APP_DB_HOST"))
+	gt.Expect(NewEnvSource(
+		WithEnvPrefix("app"),
+		WithEnvSeparator("__"),
+		WithEnvCase(EnvLowerCase),
+	).VariableName("db.maxSize")).Should(g.Equal("app__db__maxsize"))
+	gt.Expect(NewEnvSource(WithEnvCase(EnvKeepCase)).VariableName("db.maxSize")).Should(g.Equal("db_maxSize"))
+}
+
+func TestEnvSource_InjectedByPath(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	t.Setenv("YADITEST_DB_HOST", "env-host")
+	t.Setenv("YADITEST_DB_PORT", "6543")
+	t.Setenv("YADITEST_DB_REPLICAS", "a,b")
+	t.Setenv("YADITEST_DB_URL", "postgres://env-source")
+	err := LoadValues(NewEnvSource(WithEnvPrefix("YADITEST")))
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	UseLazyContext()
+
+	config, err := GetBean[*EnvConfig]()
+
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(config.Host).Should(g.Equal("env-host"))
+	g.Expect(config.Port).Should(g.Equal(6543))
+	g.Expect(config.Replicas).Should(g.Equal([]string{"a", "b"}))
+	g.Expect(config.URL).Should(g.Equal("postgres://env-source"))
+	g.Expect(config.Token).Should(g.Equal("none"))
+}
+
+func TestEnvSource_ReadOnDemand(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	UseLazyContext()
+	err := LoadValues(NewEnvSource(WithEnvPrefix("YADITEST")))
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+
+	_, err = GetValue[string]("db.host")
+	g.Expect(err).Should(g.MatchError(types.ErrNoValueFound))
+
+	t.Setenv("YADITEST_DB_HOST", "late-host")
+	g.Expect(GetValue[string]("db.host")).Should(g.Equal("late-host"))
+}
+
+func TestEnvSource_StoredValuesTakePrecedence(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	t.Setenv("YADITEST_DB_HOST", "env-host")
+	SetValue("db.host", "code-host")
+	err := LoadValues(NewEnvSource(WithEnvPrefix("YADITEST")))
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	UseLazyContext()
+
+	g.Expect(GetValue[string]("db.host")).Should(g.Equal("code-host"))
+}
+
+func TestEnvSource_ValuesByPrefix(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	t.Setenv("YADITEST_LIMITS_API", "10")
+	t.Setenv("YADITEST_LIMITS_DB", "5")
+	t.Setenv("yaditest_limits_ignored", "1")
+	err := LoadValues(NewEnvSource(WithEnvPrefix("YADITEST")))
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	UseLazyContext()
+
+	limits, err := GetValue[map[string]int]("limits")
+
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(limits).Should(g.Equal(map[string]int{"api": 10, "db": 5}))
+}
+
+func TestEnvSource_ValuesByPrefix_MergedWithStoredValues(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	t.Setenv("YADITEST_LIMITS_API", "10")
+	t.Setenv("YADITEST_LIMITS_DB", "5")
+	SetValue("limits.db", 7)
+	err := LoadValues(NewEnvSource(WithEnvPrefix("YADITEST")))
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	UseLazyContext()
+
+	limits, err := GetValue[map[string]int]("limits")
+
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(limits).Should(g.Equal(map[string]int{"api": 10, "db": 7}))
+}
+
+func TestEnvTag(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	t.Setenv("DATABASE_URL", "postgres://env")
+	t.Setenv("API_TOKEN", "secret")
+	err := LoadValues(JSONSource("config.json", strings.NewReader(`{"db": {"url": "postgres://file"}}`)))
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	SetValue("db.host", "localhost")
+	UseLazyContext()
+
+	config, err := GetBean[*EnvConfig]()
+
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(config.URL).Should(g.Equal("postgres://env"))
+	g.Expect(config.Token).Should(g.Equal("secret"))
+}
+
+func TestEnvTag_ExplicitValueTakesPrecedence(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	t.Setenv("DATABASE_URL", "postgres://env")
+	t.Setenv("API_TOKEN", "secret")
+	SetValue("db.url", "postgres://code")
+	SetValue("db.host", "localhost")
+	UseLazyContext()
+
+	config, err := GetBean[*EnvConfig]()
+
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(config.URL).Should(g.Equal("postgres://code"))
+}
+
+func TestEnvTag_NotSet_FallsBackToPath(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	SetValue("db.url", "postgres://code")
+	SetValue("db.host", "localhost")
+	UseLazyContext()
+
+	config, err := GetBean[*EnvConfig]()
+
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(config.URL).Should(g.Equal("postgres://code"))
+}
+
+func TestEnvTag_Bind(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	t.Setenv("YADITEST_POOL_SIZE", "12")
+	UseLazyContext()
+
+	var config struct {
+		Size int `yadi:"env=YADITEST_POOL_SIZE"`
+	}
+	err := Inject(&config)
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(config.Size).Should(g.Equal(12))
+
+	type Pool struct {
+		Size int `yadi:"env=YADITEST_POOL_SIZE"`
+		Name string
+	}
+	SetValue("pool.name", "main")
+	pool, err := BindValues[Pool]("pool")
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(pool).Should(g.Equal(Pool{Size: 12, Name: "main"}))
+}
+
+func TestEnvSource_Load(t *testing.T) {
+	g.RegisterTestingT(t)
+	t.Setenv("YADITEST_SERVERS_0_HOST", "a")
+
+	values, err := NewEnvSource(WithEnvPrefix("YADITEST")).Load()
+
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(values).Should(g.HaveKeyWithValue("servers.0.host", "a"))
+	for path := range values {
+		g.Expect(strings.ToLower(path)).Should(g.Equal(path))
+	}
+}
+
+type EnvOnlyConfig struct {
+	Secret string `yadi:"env=YADITEST_SECRET"`
+}
+
+func TestEnvTag_NotSet_NamesVariable(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	UseLazyContext()
+
+	_, err := GetBean[*EnvOnlyConfig]()
+
+	g.Expect(err).Should(g.MatchError(types.ErrNoValueFound))
+	g.Expect(err.Error()).Should(g.ContainSubstring("cannot inject env YADITEST_SECRET to field Secret"))
+}
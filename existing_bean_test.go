@@ -0,0 +1,127 @@
+package yadi
+
+import (
+	g "github.com/onsi/gomega"
+	"github.com/xbl4de/yadi/types"
+	"reflect"
+	"testing"
+)
+
+type MessageReader interface {
+	Read() string
+}
+
+type MessageWriter interface {
+	Write(message string)
+}
+
+type MessageStore struct {
+	Message string `yadi:"ignore"`
+}
+
+func (s *MessageStore) Read() string {
+	return s.Message
+}
+
+func (s *MessageStore) Write(message string) {
+	s.Message = message
+}
+
+func TestProvideAsExistingBean_SameSingleton(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	ProvideAsExistingBean[MessageReader, *MessageStore]()
+	UseLazyContext()
+
+	reader, err := GetBean[MessageReader]()
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	store := RequireBean[*MessageStore]()
+
+	g.Expect(reader).Should(g.BeIdenticalTo(store))
+}
+
+type NamedStore struct {
+	Name string
+}
+
+func (s *NamedStore) Read() string {
+	return s.Name
+}
+
+func TestProvideAsExistingBean_UsesPrimaryBean(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	SetBeanProvider[*NamedStore](func(ctx types.Context) (*NamedStore, error) {
+		return &NamedStore{Name: "primary"}, nil
+	}, WithBeanName("x"), WithPrimary())
+	ProvideAsExistingBean[MessageReader, *NamedStore]()
+	UseLazyContext()
+
+	reader, err := GetBean[MessageReader]()
+
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(reader).Should(g.BeIdenticalTo(RequireBean[*NamedStore]()))
+	g.Expect(reader.Read()).Should(g.Equal("primary"))
+}
+
+func TestProvideAsExistingBean_NotImplemented_ShouldPanic(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+
+	g.Expect(func() {
+		ProvideAsExistingBean[CountInterface, *MessageStore]()
+	}).Should(g.PanicWith(g.MatchError(types.ErrIncompatibleBeanType)))
+	g.Expect(func() {
+		ProvideAsExistingBean[*ServiceE, *ServiceE]()
+	}).Should(g.PanicWith(g.MatchError(types.ErrIncompatibleBeanType)))
+}
+
+func TestProvideAsExistingBeans_SeveralInterfaces(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	UseLazyContext()
+	ProvideAsExistingBeans[*MessageStore]([]reflect.Type{
+		reflect.TypeFor[MessageReader](),
+		reflect.TypeFor[MessageWriter](),
+	})
+
+	writer := RequireBean[MessageWriter]()
+	reader := RequireBean[MessageReader]()
+	writer.Write("hello")
+
+	g.Expect(reader.Read()).Should(g.Equal("hello"))
+}
+
+func TestProvideAsExisting_NamedBeans(t *testing.T) {
+	t.Parallel()
+	gt := g.NewWithT(t)
+	c := NewContainer()
+	gt.Expect(Provide(c, func(ctx types.Context) (*MessageStore, error) {
+		return &MessageStore{Message: "primary"}, nil
+	}, WithBeanName("primary"))).Should(g.Succeed())
+	gt.Expect(Provide(c, func(ctx types.Context) (*MessageStore, error) {
+		return &MessageStore{Message: "backup"}, nil
+	}, WithBeanName("backup"))).Should(g.Succeed())
+	gt.Expect(ProvideAsExisting[MessageReader, *MessageStore](c, WithBeanName("primary"))).Should(g.Succeed())
+	gt.Expect(ProvideAsExisting[MessageReader, *MessageStore](c, WithExistingBeanName("backup"))).Should(g.Succeed())
+
+	primary, err := GetNamed[MessageReader](c, "primary")
+	gt.Expect(err).ShouldNot(g.HaveOccurred())
+	gt.Expect(primary.Read()).Should(g.Equal("primary"))
+
+	backup, err := Get[MessageReader](c)
+	gt.Expect(err).ShouldNot(g.HaveOccurred())
+	gt.Expect(backup.Read()).Should(g.Equal("backup"))
+	gt.Expect(backup).Should(g.BeIdenticalTo(RequireNamed[*MessageStore](c, "backup")))
+}
+
+func TestProvideAsExisting_MissingNamedBean(t *testing.T) {
+	t.Parallel()
+	gt := g.NewWithT(t)
+	c := NewContainer()
+	gt.Expect(ProvideAsExisting[MessageReader, *MessageStore](c, WithBeanName("unknown"))).Should(g.Succeed())
+
+	_, err := GetNamed[MessageReader](c, "unknown")
+
+	gt.Expect(err).Should(g.MatchError(types.ErrNoBeanProvider))
+}
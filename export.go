@@ -1,33 +1,50 @@
 package yadi
 
 import (
-	"github.com/pkg/errors"
-	"github.com/xbl4de/yadi/log"
+	"context"
 	"github.com/xbl4de/yadi/types"
+	"io/fs"
 	"reflect"
 )
 
 func SetBeanProvider[T types.Bean](builder func(ctx types.Context) (T, error), options ...func(provider *types.BeanProvider)) int {
-	beanType := reflect.TypeFor[T]()
-	provider := &types.BeanProvider{
-		Builder: func(ctx types.Context) (types.Bean, error) {
-			return builder(ctx)
-		},
-		BeanType:      beanType,
-		HoldByContext: true,
+	provideDefault(newBeanProvider(builder, options))
+	return dummyInt
+}
+
+func WithHoldByUser() func(provider *types.BeanProvider) {
+	return func(provider *types.BeanProvider) {
+		provider.HoldByContext = false
 	}
-	for _, option := range options {
-		option(provider)
+}
+
+func WithLazyInit() func(provider *types.BeanProvider) {
+	return func(provider *types.BeanProvider) {
+		provider.Lazy = true
 	}
+}
 
-	provideDefault[T](provider)
+func WithInitFunc[T types.Bean](initFunc func(bean T) error) func(provider *types.BeanProvider) {
+	return func(provider *types.BeanProvider) {
+		provider.InitFuncs = append(provider.InitFuncs, func(bean types.Bean) error {
+			return initFunc(bean.(T))
+		})
+	}
+}
 
-	return dummyInt
+func WithDestroyFunc[T types.Bean](destroyFunc func(ctx context.Context, bean T) error) func(provider *types.BeanProvider) {
+	return func(provider *types.BeanProvider) {
+		provider.DestroyFuncs = append(provider.DestroyFuncs, func(ctx context.Context, bean types.Bean) error {
+			return destroyFunc(ctx, bean.(T))
+		})
+	}
 }
 
-func WithHoldByUser() func(provider *types.BeanProvider) {
+// WithPrimary makes the bean the default candidate for lookups without a
+// name and for interfaces implemented by several beans.
+func WithPrimary() func(provider *types.BeanProvider) {
 	return func(provider *types.BeanProvider) {
-		provider.HoldByContext = false
+		provider.Primary = true
 	}
 }
 
@@ -37,75 +54,93 @@ func WithBeanName(name string) func(provider *types.BeanProvider) {
 	}
 }
 
-func ProvideAsExistingBean[T, E types.Bean]() int {
-	panic("not implemented")
+func WithExistingBeanName(name string) func(provider *types.BeanProvider) {
+	return func(provider *types.BeanProvider) {
+		provider.ExistingBeanName = name
+	}
+}
+
+func ProvideAsExistingBean[T, E types.Bean](options ...func(provider *types.BeanProvider)) int {
+	return ProvideAsExistingBeans[E]([]reflect.Type{reflect.TypeFor[T]()}, options...)
+}
+
+func ProvideAsExistingBeans[E types.Bean](interfaceTypes []reflect.Type, options ...func(provider *types.BeanProvider)) int {
+	for _, interfaceType := range interfaceTypes {
+		provider, err := newExistingBeanProvider(interfaceType, reflect.TypeFor[E](), options)
+		if err != nil {
+			panic(err)
+		}
+		provideDefault(provider)
+	}
+	return dummyInt
 }
 
 func GetBean[T types.Bean]() (T, error) {
 	err := ensureContext()
-	var zeroValue T
 	if err != nil {
+		var zeroValue T
 		return zeroValue, err
 	}
+	return Get[T](globalCtx)
+}
 
-	p := reflect.TypeFor[T]()
-	bean, err := globalCtx.Get(p)
+func GetNamedBean[T types.Bean](name string) (T, error) {
+	err := ensureContext()
 	if err != nil {
+		var zeroValue T
 		return zeroValue, err
 	}
-	casted, ok := bean.(T)
-	if !ok {
-		return zeroValue, errors.Errorf("Failed to cast bean to type %s: actual type is %s",
-			p.String(), reflect.TypeOf(bean).String())
-	}
-	return casted, nil
+	return GetNamed[T](globalCtx, name)
 }
 
-func GetNamedBean[T types.Bean](name string) (T, error) {
+func GetBeanCtx[T types.Bean](ctx context.Context) (T, error) {
 	err := ensureContext()
-	var zeroValue T
 	if err != nil {
+		var zeroValue T
 		return zeroValue, err
 	}
+	return GetCtx[T](globalCtx, ctx)
+}
 
-	typ := reflect.TypeFor[T]()
-	bean, err := globalCtx.GetNamed(typ, name)
+func GetNamedBeanCtx[T types.Bean](ctx context.Context, name string) (T, error) {
+	err := ensureContext()
 	if err != nil {
+		var zeroValue T
 		return zeroValue, err
 	}
-	casted, ok := bean.(T)
-	if !ok {
-		return zeroValue, errors.Errorf("Failed to cast bean to type %s: actual type is %s",
-			typ.String(), reflect.TypeOf(bean).String())
-	}
-	return casted, nil
+	return GetNamedCtx[T](globalCtx, ctx, name)
 }
 
-func RequireBean[T types.Bean]() T {
+func GetBeans[T types.Bean]() ([]T, error) {
 	err := ensureContext()
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
+	return GetAll[T](globalCtx)
+}
 
-	bean, err := GetBean[T]()
+func GetNamedBeans[T types.Bean]() (map[string]T, error) {
+	err := ensureContext()
 	if err != nil {
-		log.Log("%+v", err)
-		panic(err)
+		return nil, err
 	}
-	return bean
+	return GetAllNamed[T](globalCtx)
 }
 
-func RequireNamedBean[T types.Bean](name string) T {
+func RequireBean[T types.Bean]() T {
 	err := ensureContext()
 	if err != nil {
 		panic(err)
 	}
-	bean, err := GetNamedBean[T](name)
+	return Require[T](globalCtx)
+}
+
+func RequireNamedBean[T types.Bean](name string) T {
+	err := ensureContext()
 	if err != nil {
-		log.Log("%+v", err)
 		panic(err)
 	}
-	return bean
+	return RequireNamed[T](globalCtx, name)
 }
 
 func GetBeanOrDefault[T types.Bean](defaultValue T) T {
@@ -126,20 +161,11 @@ func GetNamedBeanOrDefault[T types.Bean](name string, defaultValue T) T {
 
 func GetValue[T interface{}](path string) (T, error) {
 	err := ensureContext()
-	var zeroValue T
 	if err != nil {
+		var zeroValue T
 		return zeroValue, err
 	}
-	val, err := globalCtx.GetGenericValue(path)
-	if err != nil {
-		return zeroValue, errors.WithMessagef(err, "Failed to get value by path: %s", path)
-	}
-	casted, ok := val.(T)
-	if !ok {
-		typeName := reflect.TypeFor[T]().String()
-		return zeroValue, errors.Errorf("expected type %s but got %T", typeName, val)
-	}
-	return casted, nil
+	return Value[T](globalCtx, path)
 }
 
 func GetValueOrDefault[T interface{}](path string, defaultValue T) T {
@@ -162,6 +188,121 @@ func SetValue[T interface{}](path string, value T) int {
 	return dummyInt
 }
 
+// SetDefaultValue sets a value in types.DefaultLayer, so any source
+// overrides it.
+func SetDefaultValue[T interface{}](path string, value T) int {
+	if globalCtx != nil {
+		globalCtx.SetLayeredValue(path, value, defaultValueOrigin)
+	} else {
+		deferredUpdates = append(deferredUpdates, func(ctx types.Context) error {
+			ctx.SetLayeredValue(path, value, defaultValueOrigin)
+			return nil
+		})
+	}
+	return dummyInt
+}
+
+// ValueOrigin tells where the value of path came from, e.g. the position in a
+// file or the name of an environment variable.
+func ValueOrigin(path string) (types.ValueOrigin, error) {
+	err := ensureContext()
+	if err != nil {
+		return types.ValueOrigin{}, err
+	}
+	return globalCtx.ValueOrigin(path)
+}
+
+func BindValues[T any](prefix string) (T, error) {
+	err := ensureContext()
+	if err != nil {
+		var zeroValue T
+		return zeroValue, err
+	}
+	return Bind[T](globalCtx, prefix)
+}
+
+// LoadValues reads the sources and sets their values. Without a context the
+// values are applied once it is created, but the sources are read right away.
+func LoadValues(sources ...types.ValueSource) error {
+	loaded, err := readSources(sources)
+	if err != nil {
+		return err
+	}
+	if globalCtx != nil {
+		applyValues(globalCtx, loaded)
+	} else {
+		deferredUpdates = append(deferredUpdates, func(ctx types.Context) error {
+			applyValues(ctx, loaded)
+			return nil
+		})
+	}
+	return nil
+}
+
+// SetActiveProfiles sets the profiles choosing providers and overlay files,
+// later profiles take precedence.
+func SetActiveProfiles(profiles ...string) int {
+	return SetValue(ActiveProfilesPath, profiles)
+}
+
+func GetActiveProfiles() ([]string, error) {
+	err := ensureContext()
+	if err != nil {
+		return nil, err
+	}
+	return ActiveProfiles(globalCtx)
+}
+
+// LoadProfileValues loads the overlays of the file for the active profiles,
+// e.g. config-prod.yaml for config.yaml, skipping missing ones. Without a
+// context the overlays are loaded once it is created.
+func LoadProfileValues(path string) error {
+	return loadProfileValuesOrDefer(path, nil)
+}
+
+// LoadProfileValuesFS is LoadProfileValues reading the overlays from fsys.
+func LoadProfileValuesFS(fsys fs.FS, path string) error {
+	return loadProfileValuesOrDefer(path, fsys)
+}
+
+func loadProfileValuesOrDefer(path string, fsys fs.FS) error {
+	if globalCtx != nil {
+		return loadProfileValues(globalCtx, path, fsys)
+	}
+	deferredUpdates = append(deferredUpdates, func(ctx types.Context) error {
+		return loadProfileValues(ctx, path, fsys)
+	})
+	return nil
+}
+
+func DeleteValue(path string) int {
+	if globalCtx != nil {
+		globalCtx.DeleteValue(path)
+	} else {
+		deferredUpdates = append(deferredUpdates, func(ctx types.Context) error {
+			ctx.DeleteValue(path)
+			return nil
+		})
+	}
+	return dummyInt
+}
+
+func GetValuesByPrefix(prefix string) (map[string]interface{}, error) {
+	err := ensureContext()
+	if err != nil {
+		return nil, err
+	}
+	return globalCtx.GetValuesByPrefix(prefix), nil
+}
+
+func GetValueKeys() ([]string, error) {
+	err := ensureContext()
+	if err != nil {
+		return nil, err
+	}
+	return globalCtx.ValueKeys(), nil
+}
+
 func NewLazyBean[T types.Bean]() types.LazyBean[T] {
 	return func() T {
 		return RequireBean[T]()
@@ -172,17 +313,27 @@ func UseLazyContext() {
 	applyContext(NewLazyContext(deferredUpdates))
 }
 
-func CloseContext() error {
-	err := ensureContext()
+func UseEagerContext() error {
+	ctx, err := NewEagerContext(deferredUpdates)
 	if err != nil {
 		return err
 	}
-	err = globalCtx.Close()
+	applyContext(ctx)
+	return nil
+}
+
+func CloseContext() error {
+	return ShutdownContext(context.Background())
+}
+
+func ShutdownContext(goCtx context.Context) error {
+	err := ensureContext()
 	if err != nil {
 		return err
 	}
+	err = globalCtx.Close(goCtx)
 	globalCtx = nil
-	return nil
+	return err
 }
 
 func WithValuePathAt(paramIndex int, path string) FuncProviderOption {
@@ -203,16 +354,20 @@ func WithDefaultValueAt(paramIndex int, defaultValue interface{}) FuncProviderOp
 	}
 }
 
+func WithOptionalAt(paramIndex int) FuncProviderOption {
+	return func(config *FuncProviderConfig) {
+		config.Parameter(paramIndex).Optional = true
+	}
+}
+
+func WithProviderOptions(options ...func(provider *types.BeanProvider)) FuncProviderOption {
+	return func(config *FuncProviderConfig) {
+		config.providerOptions = append(config.providerOptions, options...)
+	}
+}
+
 func SetBeanProviderFunc[T types.Bean](function interface{}, opts ...FuncProviderOption) int {
-	beanName := extractBeanName(opts)
-	return SetBeanProvider(func(ctx types.Context) (T, error) {
-		cfg := NewFuncProviderConfig()
-		for _, opt := range opts {
-			opt(cfg)
-		}
-		bean, err := providerFromFuncE[T](function, cfg)
-		return bean, err
-	}, WithBeanName(beanName))
+	return SetBeanProvider(funcBuilder[T](function, opts), extractProviderOptions(opts)...)
 }
 
 func InjectLazyBean[T types.Bean]() types.LazyBean[T] {
@@ -222,5 +377,9 @@ func InjectLazyBean[T types.Bean]() types.LazyBean[T] {
 }
 
 func Inject(valuePtr types.Bean) error {
-	return injectToPtr(reflect.ValueOf(valuePtr))
+	err := ensureContext()
+	if err != nil {
+		return err
+	}
+	return injectBean(globalCtx, valuePtr)
 }
@@ -0,0 +1,161 @@
+package yadi
+
+import (
+	"flag"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/xbl4de/yadi/types"
+	"strings"
+)
+
+// SetFlagName is the flag overriding any value with path=value.
+const SetFlagName = "set"
+
+// FlagSource exposes value paths as command-line flags of a flag.FlagSet.
+// Flags set on the command line and --set overrides are loaded into
+// types.FlagLayer, the defaults of declared flags into types.DefaultLayer.
+type FlagSource struct {
+	flagSet   *flag.FlagSet
+	flags     []*pathFlag
+	overrides *overridesFlag
+}
+
+// pathFlag is a flag declared for a value path.
+type pathFlag struct {
+	name         string
+	path         string
+	defaultValue interface{}
+	value        string
+	set          bool
+}
+
+func (f *pathFlag) String() string {
+	if f.set {
+		return f.value
+	}
+	if f.defaultValue == nil {
+		return ""
+	}
+	return fmt.Sprint(f.defaultValue)
+}
+
+func (f *pathFlag) Set(value string) error {
+	f.value = value
+	f.set = true
+	return nil
+}
+
+func (f *pathFlag) IsBoolFlag() bool {
+	_, ok := f.defaultValue.(bool)
+	return ok
+}
+
+type override struct {
+	path  string
+	value string
+}
+
+// overridesFlag collects repeated --set path=value flags.
+type overridesFlag struct {
+	overrides []override
+}
+
+func (f *overridesFlag) String() string {
+	if f == nil {
+		return ""
+	}
+	pairs := make([]string, 0, len(f.overrides))
+	for _, o := range f.overrides {
+		pairs = append(pairs, o.path+"="+o.value)
+	}
+	return strings.Join(pairs, " ")
+}
+
+func (f *overridesFlag) Set(value string) error {
+	path, pathValue, ok := strings.Cut(value, "=")
+	if !ok || path == "" {
+		return errors.Errorf("expected path=value, got %s", value)
+	}
+	f.overrides = append(f.overrides, override{path: path, value: pathValue})
+	return nil
+}
+
+// NewFlagSource registers the --set flag on flagSet.
+func NewFlagSource(flagSet *flag.FlagSet) *FlagSource {
+	source := &FlagSource{
+		flagSet:   flagSet,
+		overrides: &overridesFlag{},
+	}
+	flagSet.Var(source.overrides, SetFlagName, "set a value, path=value, may be repeated")
+	return source
+}
+
+// Declare registers a flag named after the path. A bool default makes the
+// flag usable without a value.
+func (s *FlagSource) Declare(path string, defaultValue interface{}, usage string) *FlagSource {
+	return s.DeclareNamed(path, path, defaultValue, usage)
+}
+
+// DeclareNamed registers a flag with the given name for the path.
+func (s *FlagSource) DeclareNamed(name string, path string, defaultValue interface{}, usage string) *FlagSource {
+	f := &pathFlag{name: name, path: path, defaultValue: defaultValue}
+	s.flagSet.Var(f, name, usage)
+	s.flags = append(s.flags, f)
+	return s
+}
+
+func (s *FlagSource) Parse(arguments []string) error {
+	return s.flagSet.Parse(arguments)
+}
+
+func (s *FlagSource) Name() string {
+	return "flags"
+}
+
+func (s *FlagSource) Layer() types.ValueLayer {
+	return types.FlagLayer
+}
+
+// Locate returns the flag setting the path, the last --set override wins over
+// declared flags as in Load.
+func (s *FlagSource) Locate(path string) string {
+	for i := len(s.overrides.overrides) - 1; i >= 0; i-- {
+		if s.overrides.overrides[i].path == path {
+			return "--" + SetFlagName + " " + path
+		}
+	}
+	for _, f := range s.flags {
+		if f.path == path {
+			return "--" + f.name
+		}
+	}
+	return ""
+}
+
+// Load returns the values of the flags set on the command line followed by
+// the --set overrides.
+func (s *FlagSource) Load() (map[string]interface{}, error) {
+	if !s.flagSet.Parsed() {
+		return nil, fmt.Errorf("%w: flags: %s is not parsed", types.ErrLoadValues, s.flagSet.Name())
+	}
+	values := make(map[string]interface{})
+	for _, f := range s.flags {
+		if f.set {
+			values[f.path] = f.value
+		}
+	}
+	for _, o := range s.overrides.overrides {
+		values[o.path] = o.value
+	}
+	return values, nil
+}
+
+func (s *FlagSource) Defaults() map[string]interface{} {
+	values := make(map[string]interface{})
+	for _, f := range s.flags {
+		if !f.set && f.defaultValue != nil {
+			values[f.path] = f.defaultValue
+		}
+	}
+	return values
+}
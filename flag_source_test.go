@@ -0,0 +1,138 @@
+package yadi
+
+import (
+	"bytes"
+	"flag"
+	g "github.com/onsi/gomega"
+	"github.com/xbl4de/yadi/types"
+	"strings"
+	"testing"
+	"time"
+)
+
+type FlagServer struct {
+	Host    string
+	Port    int
+	Debug   bool
+	Timeout time.Duration
+}
+
+func NewFlagServer(host string, port int, debug bool, timeout time.Duration) *FlagServer {
+	return &FlagServer{Host: host, Port: port, Debug: debug, Timeout: timeout}
+}
+
+func newTestFlagSource() *FlagSource {
+	flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+	flagSet.SetOutput(&bytes.Buffer{})
+	return NewFlagSource(flagSet).
+		Declare("server.host", "localhost", "server host").
+		Declare("server.port", 8080, "server port").
+		Declare("server.debug", false, "debug mode").
+		DeclareNamed("timeout", "server.timeout", "5s", "request timeout")
+}
+
+func provideFlagServer() {
+	SetBeanProviderFunc[*FlagServer](NewFlagServer,
+		WithValuePathAt(0, "server.host"),
+		WithValuePathAt(1, "server.port"),
+		WithValuePathAt(2, "server.debug"),
+		WithValuePathAt(3, "server.timeout"))
+}
+
+func TestFlagSource_ParsedFlags(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	source := newTestFlagSource()
+	err := source.Parse([]string{"-server.port=9090", "--server.debug", "--timeout", "1m"})
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	provideFlagServer()
+	UseLazyContext()
+
+	err = LoadValues(source)
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	server, err := GetBean[*FlagServer]()
+
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(server).Should(g.Equal(&FlagServer{
+		Host:    "localhost",
+		Port:    9090,
+		Debug:   true,
+		Timeout: time.Minute,
+	}))
+}
+
+func TestFlagSource_SetOverrides(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	source := newTestFlagSource()
+	err := source.Parse([]string{
+		"--server.port=9090",
+		"--set", "server.port=9191",
+		"--set", "server.host=example.com",
+		"--set", "server.host=override.com",
+		"--set", "kafka.brokers=a,b",
+	})
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	err = LoadValues(source)
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	provideFlagServer()
+	UseLazyContext()
+
+	server, err := GetBean[*FlagServer]()
+
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(server.Host).Should(g.Equal("override.com"))
+	g.Expect(server.Port).Should(g.Equal(9191))
+	g.Expect(GetValue[[]string]("kafka.brokers")).Should(g.Equal([]string{"a", "b"}))
+}
+
+func TestFlagSource_DefaultsDoNotOverrideValues(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	SetValue("server.host", "from-code")
+	source := newTestFlagSource()
+	err := source.Parse(nil)
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	err = LoadValues(source)
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	UseLazyContext()
+
+	g.Expect(GetValue[string]("server.host")).Should(g.Equal("from-code"))
+	g.Expect(GetValue[int]("server.port")).Should(g.Equal(8080))
+}
+
+func TestFlagSource_InvalidSet(t *testing.T) {
+	t.Parallel()
+	gt := g.NewWithT(t)
+	source := newTestFlagSource()
+
+	err := source.Parse([]string{"--set", "server.port"})
+
+	gt.Expect(err).Should(g.HaveOccurred())
+	gt.Expect(err.Error()).Should(g.ContainSubstring("expected path=value"))
+}
+
+func TestFlagSource_NotParsed(t *testing.T) {
+	t.Parallel()
+	gt := g.NewWithT(t)
+
+	err := NewContainer().LoadValues(newTestFlagSource())
+
+	gt.Expect(err).Should(g.MatchError(types.ErrLoadValues))
+}
+
+func TestFlagSource_Usage(t *testing.T) {
+	t.Parallel()
+	gt := g.NewWithT(t)
+	source := newTestFlagSource()
+	output := &bytes.Buffer{}
+	source.flagSet.SetOutput(output)
+
+	source.flagSet.PrintDefaults()
+
+	usage := output.String()
+	gt.Expect(usage).Should(g.ContainSubstring("server host (default localhost)"))
+	gt.Expect(usage).Should(g.ContainSubstring("server port (default 8080)"))
+	gt.Expect(usage).Should(g.ContainSubstring("-timeout"))
+	gt.Expect(strings.Count(usage, "-set")).Should(g.Equal(1))
+}
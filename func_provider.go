@@ -10,11 +10,13 @@ import (
 type ParameterConfig struct {
 	ValuePath    string
 	DefaultValue interface{}
+	Optional     bool
 }
 
 type FuncProviderConfig struct {
-	beanName   string
-	parameters map[int]*ParameterConfig
+	beanName        string
+	parameters      map[int]*ParameterConfig
+	providerOptions []func(provider *types.BeanProvider)
 }
 
 type FuncProviderOption func(*FuncProviderConfig)
@@ -38,19 +40,15 @@ func NewFuncProviderConfig() *FuncProviderConfig {
 	}
 }
 
-func extractBeanName(opts []FuncProviderOption) string {
-	beanName := ""
-	if len(opts) > 0 {
-		fakeCfg := NewFuncProviderConfig()
-		for _, opt := range opts {
-			opt(fakeCfg)
-		}
-		beanName = fakeCfg.beanName
+func extractProviderOptions(opts []FuncProviderOption) []func(provider *types.BeanProvider) {
+	fakeCfg := NewFuncProviderConfig()
+	for _, opt := range opts {
+		opt(fakeCfg)
 	}
-	return beanName
+	return append(fakeCfg.providerOptions, WithBeanName(fakeCfg.beanName))
 }
 
-func providerFromFuncE[T types.Bean](function interface{}, cfg *FuncProviderConfig) (T, error) {
+func providerFromFuncE[T types.Bean](ctx types.Context, function interface{}, cfg *FuncProviderConfig) (T, error) {
 	funcValue := reflect.ValueOf(function)
 	funcType := reflect.TypeOf(function)
 	var zeroValue T
@@ -59,7 +57,7 @@ func providerFromFuncE[T types.Bean](function interface{}, cfg *FuncProviderConf
 		return zeroValue, err
 	}
 
-	args, err := buildArgs(funcType, cfg)
+	args, err := buildArgs(ctx, funcType, cfg)
 	if err != nil {
 		return zeroValue, err
 	}
@@ -79,14 +77,19 @@ func providerFromFuncE[T types.Bean](function interface{}, cfg *FuncProviderConf
 	}
 }
 
-func buildArgs(funcType reflect.Type, cfg *FuncProviderConfig) ([]reflect.Value, error) {
+func buildArgs(ctx types.Context, funcType reflect.Type, cfg *FuncProviderConfig) ([]reflect.Value, error) {
 	args := make([]reflect.Value, funcType.NumIn())
 	for i := 0; i < funcType.NumIn(); i++ {
-		arg, err := findArgValue(funcType.In(i), cfg.Parameter(i))
-		if err != nil {
+		param := cfg.Parameter(i)
+		arg, err := findArgValue(ctx, funcType.In(i), param)
+		if err != nil && !(param.Optional && isMissingDependency(err)) {
 			return nil, errors.WithMessagef(err, "Failed to find arg at index %d", i)
 		}
-		args[i] = reflect.ValueOf(arg)
+		if arg == nil {
+			args[i] = reflect.Zero(funcType.In(i))
+		} else {
+			args[i] = reflect.ValueOf(arg)
+		}
 	}
 	return args, nil
 }
@@ -113,11 +116,28 @@ func validateProviderFunc[T interface{}](funcValue reflect.Value, funcType refle
 	return nil
 }
 
-func findArgValue(argType reflect.Type, opt *ParameterConfig) (interface{}, error) {
-	if argType.Kind() == reflect.Ptr ||
+func findArgValue(ctx types.Context, argType reflect.Type, opt *ParameterConfig) (interface{}, error) {
+	if isOptionalType(argType) {
+		return newOptional(argType, func(valueType reflect.Type) (interface{}, error) {
+			return findArgValue(ctx, valueType, opt)
+		})
+	}
+	if opt.ValuePath == "" && opt.DefaultValue == nil && isMultiBeanType(ctx, argType) {
+		return getAllBeansValue(ctx, argType)
+	}
+	if opt.ValuePath == "" && (argType.Kind() == reflect.Ptr ||
 		argType.Kind() == reflect.Interface ||
-		argType.Kind() == reflect.Struct {
-		return getBeanOrDefaultFromContext(argType, opt.DefaultValue)
+		argType.Kind() == reflect.Struct) {
+		return getBeanOrDefaultFromContext(ctx, argType, opt.DefaultValue)
+	}
+	value, err := getTypedValue(ctx, opt.ValuePath, argType)
+	if err != nil {
+		if errors.Is(err, types.ErrNoValueFound) && opt.DefaultValue != nil {
+			value, err = convertValue(opt.DefaultValue, argType)
+		}
+		if err != nil {
+			return nil, errors.WithMessagef(err, "cannot use value '%s'", opt.ValuePath)
+		}
 	}
-	return getGenericValueOrDefault(opt.ValuePath, opt.DefaultValue)
+	return value, nil
 }
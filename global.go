@@ -1,7 +1,7 @@
 package yadi
 
 import (
-	"github.com/pkg/errors"
+	"context"
 	"github.com/xbl4de/yadi/log"
 	"github.com/xbl4de/yadi/types"
 	"github.com/xbl4de/yadi/utils"
@@ -25,7 +25,7 @@ func ensureContext() error {
 	return nil
 }
 
-func provideDefault[T types.Bean](provider *types.BeanProvider) {
+func provideDefault(provider *types.BeanProvider) {
 	if globalCtx != nil {
 		err := globalCtx.Register(provider)
 		if err != nil {
@@ -36,7 +36,7 @@ func provideDefault[T types.Bean](provider *types.BeanProvider) {
 			return ctx.Register(provider)
 		})
 	}
-	log.Log("Provided default bean: %s", reflect.TypeFor[T]().String())
+	log.Log("Provided default bean: %s", provider.BeanType.String())
 }
 
 func clearDeferredUpdates() {
@@ -48,12 +48,9 @@ func closeContextSoft() error {
 	if err != nil {
 		return nil
 	}
-	err = globalCtx.Close()
-	if err != nil {
-		return err
-	}
+	err = globalCtx.Close(context.Background())
 	globalCtx = nil
-	return nil
+	return err
 }
 
 func applyContext(ctx types.Context) {
@@ -63,35 +60,20 @@ func applyContext(ctx types.Context) {
 	globalCtx = ctx
 }
 
-func getBeanFromContext(beanType reflect.Type) (types.Bean, error) {
-	val, err := getGlobalCtx().Get(beanType)
+func getBeanFromContext(ctx types.Context, beanType reflect.Type) (types.Bean, error) {
+	val, err := ctx.Get(beanType)
 	if err != nil {
 		return nil, err
 	}
 	return val, err
 }
 
-func getGenericValueOrDefault(path string, defaultValue interface{}) (interface{}, error) {
-	value, err := getGlobalCtx().GetGenericValue(path)
-	if err != nil {
-		if errors.Is(err, types.ErrNoValueFound) && defaultValue != nil {
-			return defaultValue, nil
-		}
-		return nil, err
-	}
-	return value, nil
-}
-
-func getGenericValue(path string) (interface{}, error) {
-	return getGenericValueOrDefault(path, nil)
-}
-
-func getBeanOrDefaultFromContext(beanType reflect.Type, defaultValue types.Bean) (types.Bean, error) {
+func getBeanOrDefaultFromContext(ctx types.Context, beanType reflect.Type, defaultValue types.Bean) (types.Bean, error) {
 	err := utils.ValidateTypeIsBean(beanType)
 	if err != nil {
 		return nil, err
 	}
-	bean, err := getBeanFromContext(beanType)
+	bean, err := getBeanFromContext(ctx, beanType)
 	if err != nil {
 		if types.ErrNoInjectableProvided(err) && defaultValue != nil {
 			return defaultValue, nil
@@ -5,10 +5,11 @@ import (
 	"github.com/xbl4de/yadi/log"
 	"github.com/xbl4de/yadi/types"
 	"github.com/xbl4de/yadi/utils"
+	"os"
 	"reflect"
 )
 
-func tryToBuildNewBean(beanType reflect.Type) (interface{}, error) {
+func tryToBuildNewBean(ctx types.Context, beanType reflect.Type) (interface{}, error) {
 	log.Verbose("Trying to build new bean for type %s", beanType.String())
 	err := utils.ValidateTypeIsBean(beanType)
 	if err != nil {
@@ -23,7 +24,11 @@ func tryToBuildNewBean(beanType reflect.Type) (interface{}, error) {
 	}
 
 	valPtr := reflect.New(buildType)
-	err = injectToPtr(valPtr)
+	err = injectToPtr(ctx, valPtr)
+	if err != nil {
+		return nil, err
+	}
+	err = postConstruct(valPtr.Interface(), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -36,7 +41,7 @@ func tryToBuildNewBean(beanType reflect.Type) (interface{}, error) {
 	}
 }
 
-func injectToPtr(beanStructValue reflect.Value) error {
+func injectToPtr(ctx types.Context, beanStructValue reflect.Value) error {
 	beanStructType := beanStructValue.Type()
 
 	if utils.IsTypeDoesNotSupportInjection(beanStructType) {
@@ -52,7 +57,7 @@ func injectToPtr(beanStructValue reflect.Value) error {
 
 	fieldsCount := beanStructType.NumField()
 	for i := 0; i < fieldsCount; i++ {
-		err := setField(i, beanStructValue, beanStructType, origBeanTypeValue, origBeanReflectValue)
+		err := setField(ctx, i, beanStructValue, beanStructType, origBeanTypeValue, origBeanReflectValue)
 		if err != nil {
 			return err
 		}
@@ -61,6 +66,7 @@ func injectToPtr(beanStructValue reflect.Value) error {
 }
 
 func setField(
+	ctx types.Context,
 	fieldInd int,
 	beanStructValue reflect.Value,
 	beanStructType reflect.Type,
@@ -77,8 +83,12 @@ func setField(
 	if shouldIgnoreInjection(yadiTag, field.Type) {
 		return nil
 	}
-	toInject, err := getValueToInject(field.Type, yadiTag)
+	toInject, err := getValueToInject(ctx, field, yadiTag)
 	if err != nil {
+		if yadiTag.Optional && isMissingDependency(err) {
+			log.Verbose("Skipping optional field %s.%s: %s", beanStructType.String(), field.Name, err)
+			return nil
+		}
 		return err
 	}
 
@@ -139,19 +149,82 @@ func shouldIgnoreInjection(yadiTag *types.Tag, fieldType reflect.Type) bool {
 	return yadiTag.Ignore || fieldType.Kind() == reflect.Func
 }
 
-func getValueToInject(fieldType reflect.Type, yadiTag *types.Tag) (interface{}, error) {
-	if utils.IsTypeBean(fieldType) {
-		bean, err := getBeanFromContext(fieldType)
+func getValueToInject(ctx types.Context, field reflect.StructField, yadiTag *types.Tag) (interface{}, error) {
+	fieldType := field.Type
+	if isOptionalType(fieldType) {
+		return newOptional(fieldType, func(valueType reflect.Type) (interface{}, error) {
+			valueField := field
+			valueField.Type = valueType
+			return getValueToInject(ctx, valueField, yadiTag)
+		})
+	}
+	if yadiTag.BeanName != "" {
+		bean, err := ctx.GetNamed(fieldType, yadiTag.BeanName)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "cannot inject bean '%s' to field %s", yadiTag.BeanName, field.Name)
+		}
+		return bean, nil
+	}
+	if yadiTag.ValuePath == "" && isMultiBeanType(ctx, fieldType) {
+		return getAllBeansValue(ctx, fieldType)
+	}
+	if yadiTag.ValuePath == "" && yadiTag.EnvName == "" && utils.IsTypeBean(fieldType) {
+		bean, err := getBeanFromContext(ctx, fieldType)
 		if err != nil {
 			return nil, err
 		}
 		return bean, nil
 	} else {
 		path := yadiTag.ValuePath
-		genericValue, err := getGenericValue(path)
+		envOnly := path == "" && yadiTag.EnvName != ""
+		value, found, err := getEnvTagValue(ctx, yadiTag, path, fieldType)
+		switch {
+		case !found && envOnly:
+			err = errors.Wrapf(types.ErrNoValueFound, "env %s is not set", yadiTag.EnvName)
+		case !found:
+			value, err = getTypedValue(ctx, path, fieldType)
+		}
 		if err != nil {
-			return nil, err
+			if yadiTag.HasDefault && errors.Is(err, types.ErrNoValueFound) {
+				return getDefaultValue(field, yadiTag)
+			}
+			if envOnly {
+				return nil, errors.WithMessagef(err, "cannot inject env %s to field %s", yadiTag.EnvName, field.Name)
+			}
+			return nil, errors.WithMessagef(err, "cannot inject value '%s' to field %s", path, field.Name)
+		}
+		return value, nil
+	}
+}
+
+func getDefaultValue(field reflect.StructField, yadiTag *types.Tag) (interface{}, error) {
+	value, err := convertValue(yadiTag.DefaultValue, field.Type)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "invalid default value for field %s", field.Name)
+	}
+	return value, nil
+}
+
+// getEnvTagValue reads the variable named by the env tag. It reports whether
+// the variable is set and not overridden by a value of path from a layer
+// above types.EnvLayer.
+func getEnvTagValue(ctx types.Context, yadiTag *types.Tag, path string, typ reflect.Type) (interface{}, bool, error) {
+	if yadiTag.EnvName == "" {
+		return nil, false, nil
+	}
+	value, ok := os.LookupEnv(yadiTag.EnvName)
+	if !ok {
+		return nil, false, nil
+	}
+	if path != "" {
+		origin, err := ctx.ValueOrigin(path)
+		if err == nil && origin.Layer > types.EnvLayer {
+			return nil, false, nil
 		}
-		return genericValue, nil
 	}
+	converted, err := convertValue(value, typ)
+	if err != nil {
+		return nil, true, errors.WithMessagef(err, "env %s", yadiTag.EnvName)
+	}
+	return converted, true, nil
 }
@@ -21,7 +21,7 @@ func TestAutoBeanPtrGeneration_Success(t *testing.T) {
 	ProvideDefaultValues()
 	UseLazyContext()
 
-	serviceABean, err := tryToBuildNewBean(reflect.TypeFor[*ServiceA]())
+	serviceABean, err := tryToBuildNewBean(getGlobalCtx(), reflect.TypeFor[*ServiceA]())
 	serviceA := requireType[*ServiceA](serviceABean)
 
 	g.Expect(err).ShouldNot(g.HaveOccurred())
@@ -41,7 +41,7 @@ func TestAutoBeanPtrGeneration_WhenCannotBuildInnerBean(t *testing.T) {
 	ProvideDefaultValues()
 	UseLazyContext()
 
-	_, err := tryToBuildNewBean(reflect.TypeFor[*ServiceA]())
+	_, err := tryToBuildNewBean(getGlobalCtx(), reflect.TypeFor[*ServiceA]())
 	g.Expect(err).Should(g.MatchError(errServiceF))
 }
 
@@ -49,7 +49,7 @@ func TestAutoBeanGeneration_Success(t *testing.T) {
 	ResetYadi()
 	ProvideDefaultValues()
 	UseLazyContext()
-	serviceABean, err := tryToBuildNewBean(reflect.TypeFor[ServiceA]())
+	serviceABean, err := tryToBuildNewBean(getGlobalCtx(), reflect.TypeFor[ServiceA]())
 	serviceA := requireType[ServiceA](serviceABean)
 
 	g.Expect(err).ShouldNot(g.HaveOccurred())
@@ -128,7 +128,7 @@ func TestTryToBuildNonBeanTypes_ShouldFail(t *testing.T) {
 	}
 	for _, test := range tests {
 		t.Run(fmt.Sprintf("%s_ShouldFail", test.T.String()), func(t *testing.T) {
-			_, err := tryToBuildNewBean(test.T)
+			_, err := tryToBuildNewBean(nil, test.T)
 			g.Expect(err).Should(g.MatchError(types.ErrNonBeanType))
 		})
 	}
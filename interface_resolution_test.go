@@ -0,0 +1,108 @@
+package yadi
+
+import (
+	g "github.com/onsi/gomega"
+	"github.com/xbl4de/yadi/types"
+	"testing"
+)
+
+type MessagePrinter struct {
+	Reader MessageReader
+}
+
+type MissingImplementationHolder struct {
+	Printer interface{ Print() }
+}
+
+type OtherMessageStore struct{}
+
+func (s *OtherMessageStore) Read() string {
+	return "other"
+}
+
+func TestInterfaceResolution_FieldInjection(t *testing.T) {
+	t.Parallel()
+	gt := g.NewWithT(t)
+	c := NewContainer()
+	gt.Expect(Provide(c, func(ctx types.Context) (*MessageStore, error) {
+		return &MessageStore{Message: "resolved"}, nil
+	})).Should(g.Succeed())
+
+	printer, err := Get[*MessagePrinter](c)
+
+	gt.Expect(err).ShouldNot(g.HaveOccurred())
+	gt.Expect(printer.Reader.Read()).Should(g.Equal("resolved"))
+	gt.Expect(printer.Reader).Should(g.BeIdenticalTo(Require[*MessageStore](c)))
+}
+
+func TestInterfaceResolution_FuncProviderParameter(t *testing.T) {
+	t.Parallel()
+	gt := g.NewWithT(t)
+	c := NewContainer()
+	gt.Expect(Provide(c, func(ctx types.Context) (*MessageStore, error) {
+		return &MessageStore{Message: "resolved"}, nil
+	}, WithBeanName("store"))).Should(g.Succeed())
+	gt.Expect(ProvideFunc[*MessagePrinter](c, func(reader MessageReader) *MessagePrinter {
+		return &MessagePrinter{Reader: reader}
+	})).Should(g.Succeed())
+
+	printer, err := Get[*MessagePrinter](c)
+
+	gt.Expect(err).ShouldNot(g.HaveOccurred())
+	gt.Expect(printer.Reader.Read()).Should(g.Equal("resolved"))
+}
+
+func TestInterfaceResolution_AlreadyBuiltBean(t *testing.T) {
+	t.Parallel()
+	gt := g.NewWithT(t)
+	c := NewContainer()
+	store := Require[*MessageStore](c)
+
+	reader, err := Get[MessageReader](c)
+
+	gt.Expect(err).ShouldNot(g.HaveOccurred())
+	gt.Expect(reader).Should(g.BeIdenticalTo(store))
+}
+
+func TestInterfaceResolution_Ambiguous(t *testing.T) {
+	t.Parallel()
+	gt := g.NewWithT(t)
+	c := NewContainer()
+	gt.Expect(ProvideFunc[*MessageStore](c, func() *MessageStore {
+		return &MessageStore{}
+	})).Should(g.Succeed())
+	gt.Expect(ProvideFunc[*OtherMessageStore](c, func() *OtherMessageStore {
+		return &OtherMessageStore{}
+	})).Should(g.Succeed())
+
+	_, err := Get[MessageReader](c)
+
+	gt.Expect(err).Should(g.MatchError(types.ErrAmbiguousBean))
+	gt.Expect(err.Error()).Should(g.ContainSubstring("[*yadi.MessageStore]"))
+	gt.Expect(err.Error()).Should(g.ContainSubstring("[*yadi.OtherMessageStore]"))
+}
+
+func TestInterfaceResolution_NoImplementation(t *testing.T) {
+	t.Parallel()
+	gt := g.NewWithT(t)
+	c := NewContainer()
+
+	_, err := Get[*MissingImplementationHolder](c)
+
+	gt.Expect(err).Should(g.MatchError(types.ErrNoBeanProvider))
+}
+
+func TestInterfaceResolution_FromParentContext(t *testing.T) {
+	t.Parallel()
+	gt := g.NewWithT(t)
+	parent := NewContainer()
+	gt.Expect(Provide(parent, func(ctx types.Context) (*MessageStore, error) {
+		return &MessageStore{Message: "parent"}, nil
+	})).Should(g.Succeed())
+	child := parent.Child()
+
+	reader, err := Get[MessageReader](child)
+
+	gt.Expect(err).ShouldNot(g.HaveOccurred())
+	gt.Expect(reader).Should(g.BeIdenticalTo(Require[*MessageStore](parent)))
+}
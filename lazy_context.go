@@ -1,13 +1,17 @@
 package yadi
 
 import (
+	"context"
+	stdErrors "errors"
 	"fmt"
 	"github.com/pkg/errors"
 	"github.com/xbl4de/yadi/types"
-	"io"
+	"maps"
 	"reflect"
 	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
 )
 
 type BeanKey struct {
@@ -23,19 +27,36 @@ func NewBeanKey(typ reflect.Type, name string) BeanKey {
 }
 
 type LazyContext struct {
-	beans       map[BeanKey]*types.BeanContainer
-	providers   map[BeanKey]*types.BeanProvider
-	values      map[string]interface{}
-	injectStack []BeanKey
+	mu        sync.RWMutex
+	parent    *LazyContext
+	beans     map[BeanKey]*types.BeanContainer
+	providers map[BeanKey]map[string]*types.BeanProvider
+	order     []BeanKey
+	primaries map[reflect.Type]map[string]BeanKey
+	layers    [types.ExplicitLayer + 1]valueLayer
+	builds    map[BeanKey]*beanBuild
+	created   []*types.BeanContainer
+	profiles  atomic.Pointer[profilesCache]
+}
+
+// valueLayer holds the values of a context coming from one types.ValueLayer.
+type valueLayer struct {
+	values  *valueTree
+	lookups []types.ValueLookup
+}
+
+// beanBuild is an in-flight construction of a single bean. Concurrent
+// lookups of the same key wait on done instead of building the bean again.
+type beanBuild struct {
+	done      chan struct{}
+	owner     *resolutionChain
+	container *types.BeanContainer
+	err       error
 }
 
 func NewLazyContext(updates []func(ctx types.Context) error) *LazyContext {
-	ctx := &LazyContext{
-		beans:       make(map[BeanKey]*types.BeanContainer),
-		providers:   make(map[BeanKey]*types.BeanProvider),
-		values:      make(map[string]interface{}),
-		injectStack: make([]BeanKey, 0),
-	}
+	ctx := newLazyContext()
+	ctx.AddValueLookup(profilesEnvLookup{})
 	for _, update := range updates {
 		err := update(ctx)
 		if err != nil {
@@ -45,132 +66,603 @@ func NewLazyContext(updates []func(ctx types.Context) error) *LazyContext {
 	return ctx
 }
 
+func newLazyContext() *LazyContext {
+	ctx := &LazyContext{
+		beans:     make(map[BeanKey]*types.BeanContainer),
+		providers: make(map[BeanKey]map[string]*types.BeanProvider),
+		primaries: make(map[reflect.Type]map[string]BeanKey),
+		builds:    make(map[BeanKey]*beanBuild),
+	}
+	for i := range ctx.layers {
+		ctx.layers[i].values = newValueTree()
+	}
+	return ctx
+}
+
+// NewChild creates a context that falls back to ctx for beans and values it
+// has no providers for. Providers and values registered in the child shadow
+// the parent ones, and closing the child closes only the beans it built.
+func (ctx *LazyContext) NewChild() types.Context {
+	child := newLazyContext()
+	child.parent = ctx
+	return child
+}
+
 func (ctx *LazyContext) Init() {
 	// no inits
 }
 
-func (ctx *LazyContext) Close() error {
-	for _, bean := range ctx.beans {
+// Close closes beans held by the context in reverse creation order, so every
+// bean is closed before the beans it depends on. It keeps going after
+// failures and returns them joined. If goCtx is done before all beans are
+// closed, Close returns without waiting for the remaining ones. Closed beans
+// are forgotten, so closing again is a no-op and later lookups build new ones.
+func (ctx *LazyContext) Close(goCtx context.Context) error {
+	ctx.mu.Lock()
+	beans := ctx.created
+	ctx.created = nil
+	ctx.beans = make(map[BeanKey]*types.BeanContainer)
+	ctx.mu.Unlock()
+	slices.Reverse(beans)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- closeBeans(goCtx, beans)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-goCtx.Done():
+		return errors.Wrap(goCtx.Err(), "failed to close context")
+	}
+}
+
+func closeBeans(goCtx context.Context, beans []*types.BeanContainer) error {
+	var errs []error
+	for _, bean := range beans {
+		if goCtx.Err() != nil {
+			break
+		}
 		if !bean.HoldByContext {
 			continue
 		}
-		closeable, ok := bean.Bean.(io.Closer)
-		if ok {
-			err := closeable.Close()
-			if err != nil {
-				return err
-			}
+		err := destroyBean(goCtx, bean)
+		if err != nil {
+			errs = append(errs, errors.WithMessagef(err, "failed to close bean %s[%s]", bean.Name, bean.Type.String()))
 		}
 	}
-	return nil
+	return stdErrors.Join(errs...)
 }
 
 func keyFromProvider(provider *types.BeanProvider) BeanKey {
 	return NewBeanKey(provider.BeanType, provider.BeanName)
 }
 
+// Register adds the provider for its key and profile, replacing the provider
+// registered for both before.
 func (ctx *LazyContext) Register(provider *types.BeanProvider) error {
 	key := keyFromProvider(provider)
-	ctx.providers[key] = provider
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	primaries, ok := ctx.primaries[key.Type]
+	if !ok {
+		primaries = make(map[string]BeanKey)
+		ctx.primaries[key.Type] = primaries
+	}
+	primaryKey, hasPrimary := primaries[provider.Profile]
+	if provider.Primary && hasPrimary && primaryKey != key {
+		return errors.Wrapf(types.ErrMultiplePrimaryBeans, "cannot register %s: %s is already primary",
+			formatBeanKey(key), formatBeanKey(primaryKey))
+	}
+	if provider.Primary {
+		primaries[provider.Profile] = key
+	} else if hasPrimary && primaryKey == key {
+		delete(primaries, provider.Profile)
+	}
+	byProfile, ok := ctx.providers[key]
+	if !ok {
+		byProfile = make(map[string]*types.BeanProvider)
+		ctx.providers[key] = byProfile
+		ctx.order = append(ctx.order, key)
+	}
+	byProfile[provider.Profile] = provider
 	return nil
 }
 
+// registeredProviders returns the providers of the active profiles.
+func (ctx *LazyContext) registeredProviders() []*types.BeanProvider {
+	profiles := ctx.activeProfiles()
+	ctx.mu.RLock()
+	defer ctx.mu.RUnlock()
+	providers := make([]*types.BeanProvider, 0, len(ctx.order))
+	for _, key := range ctx.order {
+		if provider := activeProvider(ctx.providers[key], profiles); provider != nil {
+			providers = append(providers, provider)
+		}
+	}
+	return providers
+}
+
 func (ctx *LazyContext) Get(typ reflect.Type) (types.Bean, error) {
-	return ctx.get(NewBeanKey(typ, ""), true)
+	return newResolution(ctx).Get(typ)
 }
+
 func (ctx *LazyContext) GetNamed(typ reflect.Type, beanName string) (types.Bean, error) {
-	return ctx.get(NewBeanKey(typ, beanName), false)
+	return newResolution(ctx).GetNamed(typ, beanName)
+}
+
+func (ctx *LazyContext) GetAll(typ reflect.Type) ([]*types.BeanContainer, error) {
+	return newResolution(ctx).GetAll(typ)
+}
+
+func (ctx *LazyContext) GetWithContext(goCtx context.Context, typ reflect.Type) (types.Bean, error) {
+	return newResolutionWithContext(ctx, goCtx).Get(typ)
 }
 
-func (ctx *LazyContext) get(key BeanKey, buildIfNotFound bool) (types.Bean, error) {
-	var err error
-	err = ctx.pushInjectStack(key)
-	defer ctx.popInjectStack()
+func (ctx *LazyContext) GetNamedWithContext(goCtx context.Context, typ reflect.Type, beanName string) (types.Bean, error) {
+	return newResolutionWithContext(ctx, goCtx).GetNamed(typ, beanName)
+}
 
+func (ctx *LazyContext) get(r *resolution, key BeanKey, buildIfNotFound bool) (types.Bean, error) {
+	if ctx.parent != nil && !ctx.providesLocally(key) && ctx.parent.provides(key) {
+		return ctx.parent.get(r.in(ctx.parent), key, buildIfNotFound)
+	}
+	next, err := r.push(key)
 	if err != nil {
 		return nil, err
 	}
+
+	profiles := ctx.activeProfiles()
+	ctx.mu.Lock()
 	if bean, ok := ctx.beans[key]; ok {
+		ctx.mu.Unlock()
 		return bean.Bean, nil
 	}
-	beanContainer, err := ctx.initBean(key, buildIfNotFound)
+	provider := activeProvider(ctx.providers[key], profiles)
+	if provider != nil && provider.Scope != nil {
+		ctx.mu.Unlock()
+		return ctx.getScoped(next, key, provider)
+	}
+	if build, ok := ctx.builds[key]; ok {
+		ctx.mu.Unlock()
+		beanContainer, err := next.waitFor(build)
+		if err != nil {
+			return nil, err
+		}
+		return beanContainer.Bean, nil
+	}
+	build := &beanBuild{
+		done:  make(chan struct{}),
+		owner: r.chain,
+	}
+	ctx.builds[key] = build
+	ctx.mu.Unlock()
+
+	beanContainer, err := ctx.initBean(next, key, provider, buildIfNotFound)
 	if err != nil {
-		return nil, errors.WithMessagef(err, "failed to init bean %s[%s]", key.Name, key.Type.String())
+		err = errors.WithMessagef(err, "failed to init bean %s[%s]", key.Name, key.Type.String())
+	}
+
+	ctx.mu.Lock()
+	delete(ctx.builds, key)
+	if err == nil {
+		ctx.beans[key] = beanContainer
+		ctx.created = append(ctx.created, beanContainer)
+	}
+	ctx.mu.Unlock()
+
+	build.container, build.err = beanContainer, err
+	close(build.done)
+
+	if err != nil {
+		return nil, err
 	}
 	return beanContainer.Bean, nil
 }
 
-func (ctx *LazyContext) pushInjectStack(key BeanKey) error {
-	if slices.Contains(ctx.injectStack, key) {
-		diStack := ctx.dumpDiStack(key)
-		return fmt.Errorf("%w: cannot inject to\n%s", types.ErrCycleDependencies, diStack)
+func (ctx *LazyContext) getScoped(r *resolution, key BeanKey, provider *types.BeanProvider) (types.Bean, error) {
+	request := types.ScopeRequest{
+		Context: context.WithValue(r.goCtx, resolutionKey{}, r),
+		Type:    key.Type,
+		Name:    key.Name,
 	}
-	ctx.injectStack = append(ctx.injectStack, key)
-	return nil
+	bean, err := provider.Scope.Get(request, func() (*types.BeanContainer, error) {
+		return ctx.initBean(r, key, provider, false)
+	})
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to init bean %s[%s]", key.Name, key.Type.String())
+	}
+	return bean, nil
+}
+
+// primaryKey returns the key of the primary provider of typ. Primary
+// providers of the child shadow the parent ones, and primary providers of
+// later active profiles shadow the ones of earlier profiles or without one.
+func (ctx *LazyContext) primaryKey(typ reflect.Type) (BeanKey, bool) {
+	for el := ctx; el != nil; el = el.parent {
+		profiles := el.activeProfiles()
+		el.mu.RLock()
+		key, ok := el.activePrimary(typ, profiles)
+		el.mu.RUnlock()
+		if ok {
+			return key, true
+		}
+	}
+	return BeanKey{}, false
+}
+
+// activePrimary looks up the primaries registered for typ, it must be called
+// while holding ctx.mu. A primary is skipped if a provider of a later active
+// profile replaces it.
+func (ctx *LazyContext) activePrimary(typ reflect.Type, profiles []string) (BeanKey, bool) {
+	primaries := ctx.primaries[typ]
+	if len(primaries) == 0 {
+		return BeanKey{}, false
+	}
+	for i := len(profiles); i >= 0; i-- {
+		profile := ""
+		if i > 0 {
+			profile = profiles[i-1]
+		}
+		key, ok := primaries[profile]
+		if !ok {
+			continue
+		}
+		if provider := activeProvider(ctx.providers[key], profiles); provider.Profile == profile {
+			return key, true
+		}
+	}
+	return BeanKey{}, false
 }
 
-func (ctx *LazyContext) popInjectStack() {
-	if len(ctx.injectStack) == 0 {
-		return
+func (ctx *LazyContext) findProvider(key BeanKey) *types.BeanProvider {
+	for el := ctx; el != nil; el = el.parent {
+		profiles := el.activeProfiles()
+		el.mu.RLock()
+		provider := activeProvider(el.providers[key], profiles)
+		el.mu.RUnlock()
+		if provider != nil {
+			return provider
+		}
 	}
-	ctx.injectStack = ctx.injectStack[:len(ctx.injectStack)-1]
+	return nil
+}
+
+func (ctx *LazyContext) providesLocally(key BeanKey) bool {
+	profiles := ctx.activeProfiles()
+	ctx.mu.RLock()
+	defer ctx.mu.RUnlock()
+	_, hasBean := ctx.beans[key]
+	_, hasBuild := ctx.builds[key]
+	hasProvider := activeProvider(ctx.providers[key], profiles) != nil
+	return hasBean || hasBuild || hasProvider
 }
 
-func (ctx *LazyContext) dumpDiStack(toAppend BeanKey) string {
-	builder := strings.Builder{}
-	firstEl := ctx.injectStack[0]
-	builder.WriteString(fmt.Sprintf("%s[%s]\n", firstEl.Name, firstEl.Type.String()))
-	for _, el := range ctx.injectStack[1:] {
-		builder.WriteString(fmt.Sprintf("↳  %s[%s]\n", el.Name, el.Type.String()))
+func (ctx *LazyContext) provides(key BeanKey) bool {
+	for el := ctx; el != nil; el = el.parent {
+		if el.providesLocally(key) {
+			return true
+		}
 	}
-	builder.WriteString(fmt.Sprintf("→ %s[%s]\n", toAppend.Name, toAppend.Type.String()))
-	return builder.String()
+	return false
 }
 
-func (ctx *LazyContext) initBean(key BeanKey, shouldTryBuildNewBean bool) (*types.BeanContainer, error) {
-	var provider *types.BeanProvider
-	var ok bool
-	var beanContainer *types.BeanContainer
-	if provider, ok = ctx.providers[key]; !ok {
+func (ctx *LazyContext) initBean(
+	r *resolution,
+	key BeanKey,
+	provider *types.BeanProvider,
+	shouldTryBuildNewBean bool,
+) (*types.BeanContainer, error) {
+	if provider == nil {
 		if !shouldTryBuildNewBean {
 			return nil, types.ErrNoBeanProvider
 		}
-		return ctx.buildTheBean(key)
+		if key.Type.Kind() == reflect.Interface {
+			return ctx.resolveInterface(r, key)
+		}
+		return ctx.buildTheBean(r, key)
 	}
 
 	if provider.UseExistingBean != nil {
-		existingBean, err := ctx.get(NewBeanKey(provider.UseExistingBean, key.Name), false)
-		if err != nil {
-			return nil, err
+		var existingBean types.Bean
+		var err error
+		if provider.ExistingBeanName == "" {
+			existingBean, err = r.Get(provider.UseExistingBean)
+		} else {
+			existingBean, err = r.GetNamed(provider.UseExistingBean, provider.ExistingBeanName)
 		}
-		beanContainer = types.NewBeanContainerHoldByUser(existingBean, key.Name, key.Type)
-	} else {
-		bean, err := provider.Builder(ctx)
 		if err != nil {
-			return nil, err
+			return nil, newBuildError(err)
 		}
-		beanContainer = types.NewBeanContainer(bean, key.Name, key.Type, provider.HoldByContext)
+		return types.NewBeanContainerHoldByUser(existingBean, key.Name, key.Type), nil
 	}
-	ctx.beans[key] = beanContainer
+	bean, err := provider.Builder(r)
+	if err != nil {
+		return nil, newBuildError(err)
+	}
+	err = postConstruct(bean, provider.InitFuncs)
+	if err != nil {
+		return nil, newBuildError(err)
+	}
+	beanContainer := types.NewBeanContainer(bean, key.Name, key.Type, provider.HoldByContext)
+	beanContainer.DestroyFuncs = provider.DestroyFuncs
 	return beanContainer, nil
 }
 
-func (ctx *LazyContext) buildTheBean(key BeanKey) (*types.BeanContainer, error) {
-	val, err := tryToBuildNewBean(key.Type)
+func (ctx *LazyContext) buildTheBean(r *resolution, key BeanKey) (*types.BeanContainer, error) {
+	val, err := tryToBuildNewBean(r, key.Type)
 	if err != nil {
-		return nil, err
+		return nil, newBuildError(err)
 	}
 	beanContainer := types.NewBeanContainerHoldByContext(val, key.Name, key.Type)
 	return beanContainer, nil
 }
 
+// resolveInterface injects the only bean implementing the interface, or the
+// primary one if there are several. Both registered providers and already
+// built beans are considered.
+func (ctx *LazyContext) resolveInterface(r *resolution, key BeanKey) (*types.BeanContainer, error) {
+	candidates := ctx.implementations(key.Type)
+	if len(candidates) > 1 {
+		primaries := slices.DeleteFunc(slices.Clone(candidates), func(candidate BeanKey) bool {
+			provider := ctx.findProvider(candidate)
+			return provider == nil || !provider.Primary
+		})
+		if len(primaries) > 1 {
+			return nil, errors.Wrapf(types.ErrMultiplePrimaryBeans, "%s is implemented by primary beans %s",
+				key.Type.String(), formatBeanKeys(primaries))
+		}
+		if len(primaries) == 1 {
+			candidates = primaries
+		}
+	}
+	switch len(candidates) {
+	case 0:
+		return nil, errors.Wrapf(types.ErrNoBeanProvider, "no bean implements %s", key.Type.String())
+	case 1:
+		candidate := candidates[0]
+		bean, err := ctx.get(r, candidate, candidate.Name == "")
+		if err != nil {
+			return nil, err
+		}
+		return types.NewBeanContainerHoldByUser(bean, key.Name, key.Type), nil
+	default:
+		return nil, errors.Wrapf(types.ErrAmbiguousBean, "%s is implemented by %s",
+			key.Type.String(), formatBeanKeys(candidates))
+	}
+}
+
+func (ctx *LazyContext) implementations(interfaceType reflect.Type) []BeanKey {
+	found := make(map[BeanKey]bool)
+	candidates := make([]BeanKey, 0)
+	addCandidate := func(key BeanKey) {
+		if key.Type != interfaceType && key.Type.Implements(interfaceType) && !found[key] {
+			found[key] = true
+			candidates = append(candidates, key)
+		}
+	}
+	for el := ctx; el != nil; el = el.parent {
+		profiles := el.activeProfiles()
+		el.mu.RLock()
+		for _, key := range el.order {
+			provider := activeProvider(el.providers[key], profiles)
+			if provider != nil && provider.UseExistingBean == nil {
+				addCandidate(key)
+			}
+		}
+		for key := range el.beans {
+			if _, ok := el.providers[key]; !ok && key.Type.Kind() != reflect.Interface {
+				addCandidate(key)
+			}
+		}
+		el.mu.RUnlock()
+	}
+	slices.SortStableFunc(candidates, func(a, b BeanKey) int {
+		return strings.Compare(formatBeanKey(a), formatBeanKey(b))
+	})
+	return candidates
+}
+
+func (ctx *LazyContext) getAll(r *resolution, typ reflect.Type) ([]*types.BeanContainer, error) {
+	providers := ctx.matchingProviders(typ)
+	beans := make([]*types.BeanContainer, 0, len(providers))
+	for _, provider := range providers {
+		key := keyFromProvider(provider)
+		bean, err := ctx.get(r, key, false)
+		if err != nil {
+			return nil, err
+		}
+		beans = append(beans, types.NewBeanContainerHoldByUser(bean, key.Name, key.Type))
+	}
+	return beans, nil
+}
+
+// matchingProviders returns providers of typ, or of types implementing typ if
+// it is an interface, sorted by order and then by registration.
+func (ctx *LazyContext) matchingProviders(typ reflect.Type) []*types.BeanProvider {
+	var contexts []*LazyContext
+	for el := ctx; el != nil; el = el.parent {
+		contexts = append(contexts, el)
+	}
+	slices.Reverse(contexts)
+
+	found := make(map[BeanKey]int)
+	providers := make([]*types.BeanProvider, 0)
+	for _, el := range contexts {
+		profiles := el.activeProfiles()
+		el.mu.RLock()
+		for _, key := range el.order {
+			provider := activeProvider(el.providers[key], profiles)
+			if provider == nil || provider.UseExistingBean != nil {
+				continue
+			}
+			if key.Type != typ && (typ.Kind() != reflect.Interface || !key.Type.Implements(typ)) {
+				continue
+			}
+			if i, ok := found[key]; ok {
+				providers[i] = provider
+			} else {
+				found[key] = len(providers)
+				providers = append(providers, provider)
+			}
+		}
+		el.mu.RUnlock()
+	}
+	slices.SortStableFunc(providers, func(a, b *types.BeanProvider) int {
+		return a.Order - b.Order
+	})
+	return providers
+}
+
+func formatBeanKey(key BeanKey) string {
+	return fmt.Sprintf("%s[%s]", key.Name, key.Type.String())
+}
+
+func formatBeanKeys(keys []BeanKey) string {
+	formatted := make([]string, 0, len(keys))
+	for _, key := range keys {
+		formatted = append(formatted, formatBeanKey(key))
+	}
+	return strings.Join(formatted, ", ")
+}
+
+// GetGenericValue returns the value of path from the highest layer having
+// it, stored or resolved by a value lookup. Values stored under path are
+// assembled into a map, or into a list if their keys are indexes.
 func (ctx *LazyContext) GetGenericValue(path string) (interface{}, error) {
-	if val, ok := ctx.values[path]; ok {
-		return val, nil
+	value, _, err := ctx.getValue(path)
+	return value, err
+}
+
+// ValueOrigin tells where the value of path came from. Values assembled from
+// the ones stored under path have no origin of their own.
+func (ctx *LazyContext) ValueOrigin(path string) (types.ValueOrigin, error) {
+	_, origin, err := ctx.getValue(path)
+	if err != nil {
+		return types.ValueOrigin{}, err
+	}
+	if origin.Source == "" {
+		return types.ValueOrigin{}, errors.Wrapf(types.ErrNoValueFound, "%s holds only nested values", path)
+	}
+	return origin, nil
+}
+
+func (ctx *LazyContext) getValue(path string) (interface{}, types.ValueOrigin, error) {
+	for layer := types.ExplicitLayer; layer >= types.DefaultLayer; layer-- {
+		ctx.mu.RLock()
+		node, ok := ctx.layers[layer].values.node(path)
+		hasValue := ok && node.hasValue
+		hasChildren := ok && len(node.children) > 0
+		var value interface{}
+		var origin types.ValueOrigin
+		if hasValue {
+			value, origin = node.value, node.origin
+		}
+		lookups := ctx.layers[layer].lookups
+		ctx.mu.RUnlock()
+		if hasValue {
+			return value, origin, nil
+		}
+		if hasChildren {
+			return expandPaths(ctx.GetValuesByPrefix(path)), types.ValueOrigin{}, nil
+		}
+		for i := len(lookups) - 1; i >= 0; i-- {
+			if value, found := lookups[i].Lookup(path); found {
+				return value, sourceOrigin(lookups[i], layer, path), nil
+			}
+		}
+		if path != "" && slices.ContainsFunc(lookups, func(lookup types.ValueLookup) bool {
+			return len(lookupValuesByPrefix(lookup, path)) > 0
+		}) {
+			return expandPaths(ctx.GetValuesByPrefix(path)), types.ValueOrigin{}, nil
+		}
+	}
+	if ctx.parent != nil {
+		return ctx.parent.getValue(path)
 	}
-	return nil, types.ErrNoValueFound
+	return nil, types.ValueOrigin{}, types.ErrNoValueFound
 }
 
+var defaultValueOrigin = types.ValueOrigin{Layer: types.DefaultLayer, Source: "code"}
+
+// SetGenericValue sets the value in types.ExplicitLayer, so it overrides the
+// values of any source.
 func (ctx *LazyContext) SetGenericValue(path string, value interface{}) {
-	ctx.values[path] = value
+	ctx.SetLayeredValue(path, value, types.ValueOrigin{Layer: types.ExplicitLayer, Source: "code"})
+}
+
+// SetLayeredValue sets the value in the layer of its origin, replacing only
+// the values of that layer.
+func (ctx *LazyContext) SetLayeredValue(path string, value interface{}, origin types.ValueOrigin) {
+	if origin.Layer < types.DefaultLayer || origin.Layer > types.ExplicitLayer {
+		panic(fmt.Sprintf("unknown value layer %d", int(origin.Layer)))
+	}
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	ctx.layers[origin.Layer].values.set(path, value, origin)
+	valuesVersion.Add(1)
+}
+
+// DeleteValue removes the value at path and everything stored under it from
+// every layer. Values of lookups and of the parent context stay visible.
+func (ctx *LazyContext) DeleteValue(path string) {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	for _, layer := range ctx.layers {
+		layer.values.delete(path)
+	}
+	valuesVersion.Add(1)
+}
+
+// AddValueLookup adds a source resolving values on demand to its layer.
+// Lookups added later take precedence within the layer.
+func (ctx *LazyContext) AddValueLookup(lookup types.ValueLookup) {
+	layer := sourceLayer(lookup, types.EnvLayer)
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	ctx.layers[layer].lookups = append(slices.Clip(ctx.layers[layer].lookups), lookup)
+	valuesVersion.Add(1)
+}
+
+// GetValuesByPrefix returns the values stored under prefix keyed by their path
+// relative to it. Values of higher layers shadow the ones of lower layers,
+// and values of the context shadow the ones of its parent.
+func (ctx *LazyContext) GetValuesByPrefix(prefix string) map[string]interface{} {
+	values := make(map[string]interface{})
+	if ctx.parent != nil {
+		maps.Copy(values, ctx.parent.GetValuesByPrefix(prefix))
+	}
+	for layer := range ctx.layers {
+		ctx.mu.RLock()
+		lookups := ctx.layers[layer].lookups
+		ctx.mu.RUnlock()
+		for _, lookup := range lookups {
+			maps.Copy(values, lookupValuesByPrefix(lookup, prefix))
+		}
+		ctx.mu.RLock()
+		maps.Copy(values, ctx.layers[layer].values.leaves(prefix))
+		ctx.mu.RUnlock()
+	}
+	return values
+}
+
+func lookupValuesByPrefix(lookup types.ValueLookup, prefix string) map[string]interface{} {
+	values := make(map[string]interface{})
+	loaded, err := lookup.Load()
+	if err != nil {
+		return values
+	}
+	for path, value := range loaded {
+		if prefix == "" {
+			values[path] = value
+		} else if relativePath, ok := strings.CutPrefix(path, prefix+"."); ok {
+			values[relativePath] = value
+		}
+	}
+	return values
+}
+
+// ValueKeys returns the sorted paths of all values visible in the context.
+func (ctx *LazyContext) ValueKeys() []string {
+	return slices.Sorted(maps.Keys(ctx.GetValuesByPrefix("")))
 }
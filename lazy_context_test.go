@@ -0,0 +1,245 @@
+package yadi
+
+import (
+	"context"
+	g "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"github.com/xbl4de/yadi/types"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type ConcurrentA struct {
+	B *ConcurrentB
+}
+
+type ConcurrentB struct {
+	A *ConcurrentA
+}
+
+func TestLazyContext_ConcurrentGetBean_BuildsOnce(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	UseLazyContext()
+
+	var builds atomic.Int32
+	SetBeanProvider[*ServiceE](func(ctx types.Context) (*ServiceE, error) {
+		builds.Add(1)
+		time.Sleep(10 * time.Millisecond)
+		return NewServiceE("concurrent"), nil
+	})
+
+	const goroutines = 16
+	results := make([]*ServiceE, goroutines)
+	errs := make([]error, goroutines)
+	wg := sync.WaitGroup{}
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = GetBean[*ServiceE]()
+		}(i)
+	}
+	wg.Wait()
+
+	g.Expect(builds.Load()).Should(g.Equal(int32(1)))
+	for i := 0; i < goroutines; i++ {
+		g.Expect(errs[i]).ShouldNot(g.HaveOccurred())
+		g.Expect(results[i]).Should(g.BeIdenticalTo(results[0]))
+	}
+}
+
+func TestLazyContext_ConcurrentGetBean_AutoBuiltOnce(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	ProvideDefaultValues()
+	UseLazyContext()
+
+	const goroutines = 16
+	results := make([]*ServiceA, goroutines)
+	wg := sync.WaitGroup{}
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = GetBean[*ServiceA]()
+		}(i)
+	}
+	wg.Wait()
+
+	g.Expect(results[0]).ShouldNot(g.BeNil())
+	for i := 0; i < goroutines; i++ {
+		g.Expect(results[i]).Should(g.BeIdenticalTo(results[0]))
+	}
+}
+
+func TestLazyContext_ConcurrentCycle_ShouldFailInsteadOfDeadlock(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	UseLazyContext()
+
+	startedA := make(chan struct{})
+	startedB := make(chan struct{})
+	SetBeanProvider[*ConcurrentA](func(ctx types.Context) (*ConcurrentA, error) {
+		close(startedA)
+		<-startedB
+		b, err := ctx.Get(reflect.TypeFor[*ConcurrentB]())
+		if err != nil {
+			return nil, err
+		}
+		return &ConcurrentA{B: b.(*ConcurrentB)}, nil
+	})
+	SetBeanProvider[*ConcurrentB](func(ctx types.Context) (*ConcurrentB, error) {
+		close(startedB)
+		<-startedA
+		a, err := ctx.Get(reflect.TypeFor[*ConcurrentA]())
+		if err != nil {
+			return nil, err
+		}
+		return &ConcurrentB{A: a.(*ConcurrentA)}, nil
+	})
+
+	errs := make(chan error, 2)
+	go func() {
+		_, err := GetBean[*ConcurrentA]()
+		errs <- err
+	}()
+	go func() {
+		_, err := GetBean[*ConcurrentB]()
+		errs <- err
+	}()
+
+	for i := 0; i < 2; i++ {
+		var err error
+		g.Eventually(errs).Should(g.Receive(&err))
+		g.Expect(err).Should(g.MatchError(types.ErrCycleDependencies))
+	}
+}
+
+func TestLazyContext_ConcurrentGetValue(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	UseLazyContext()
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 16; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			SetValue("concurrent.value", i)
+		}()
+		go func() {
+			defer wg.Done()
+			_, _ = GetValue[int]("concurrent.value")
+		}()
+	}
+	wg.Wait()
+
+	_, err := GetValue[int]("concurrent.value")
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+}
+
+type closeRecorder struct {
+	mu     sync.Mutex
+	closed []string
+}
+
+func (r *closeRecorder) record(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = append(r.closed, name)
+}
+
+var closedBeans = &closeRecorder{}
+
+type ClosingPool struct {
+	Err error `yadi:"ignore"`
+}
+
+func (p *ClosingPool) Close() error {
+	closedBeans.record("pool")
+	return p.Err
+}
+
+type ClosingRepository struct {
+	Pool *ClosingPool
+	Err  error `yadi:"ignore"`
+}
+
+func (r *ClosingRepository) Close() error {
+	closedBeans.record("repository")
+	return r.Err
+}
+
+type HangingCloser struct {
+	release chan struct{}
+}
+
+func (h *HangingCloser) Close() error {
+	<-h.release
+	return nil
+}
+
+func TestLazyContext_Close_InReverseCreationOrder(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	closedBeans = &closeRecorder{}
+	UseLazyContext()
+
+	_, err := GetBean[*ClosingRepository]()
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+
+	err = CloseContext()
+
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(closedBeans.closed).Should(g.Equal([]string{"repository", "pool"}))
+}
+
+func TestLazyContext_Close_JoinsErrors(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	closedBeans = &closeRecorder{}
+	errPool := errors.New("errPool")
+	errRepository := errors.New("errRepository")
+	SetBeanProvider[*ClosingPool](func(ctx types.Context) (*ClosingPool, error) {
+		return &ClosingPool{Err: errPool}, nil
+	})
+	SetBeanProviderFunc[*ClosingRepository](func(pool *ClosingPool) *ClosingRepository {
+		return &ClosingRepository{Pool: pool, Err: errRepository}
+	})
+	UseLazyContext()
+
+	_, err := GetBean[*ClosingRepository]()
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+
+	err = CloseContext()
+
+	g.Expect(err).Should(g.MatchError(errPool))
+	g.Expect(err).Should(g.MatchError(errRepository))
+	g.Expect(err.Error()).Should(g.ContainSubstring("*yadi.ClosingPool"))
+	g.Expect(err.Error()).Should(g.ContainSubstring("*yadi.ClosingRepository"))
+	g.Expect(closedBeans.closed).Should(g.Equal([]string{"repository", "pool"}))
+}
+
+func TestLazyContext_Close_StopsAtDeadline(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	hanging := &HangingCloser{release: make(chan struct{})}
+	defer close(hanging.release)
+	SetBeanProvider[*HangingCloser](func(ctx types.Context) (*HangingCloser, error) {
+		return hanging, nil
+	})
+	UseLazyContext()
+
+	_, err := GetBean[*HangingCloser]()
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+
+	goCtx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err = ShutdownContext(goCtx)
+
+	g.Expect(err).Should(g.MatchError(context.DeadlineExceeded))
+}
@@ -0,0 +1,56 @@
+package yadi
+
+import (
+	"context"
+	stdErrors "errors"
+	"github.com/pkg/errors"
+	"github.com/xbl4de/yadi/types"
+	"io"
+)
+
+func postConstruct(bean types.Bean, initFuncs []types.InitFunc) error {
+	if afterInjector, ok := bean.(types.AfterInjector); ok {
+		err := afterInjector.AfterInject()
+		if err != nil {
+			return errors.WithMessagef(err, "AfterInject failed for %T", bean)
+		}
+	}
+	if validator, ok := bean.(types.Validator); ok {
+		err := validator.Validate()
+		if err != nil {
+			return errors.WithMessagef(err, "Validate failed for %T", bean)
+		}
+	}
+	for _, initFunc := range initFuncs {
+		err := initFunc(bean)
+		if err != nil {
+			return errors.WithMessagef(err, "init func failed for %T", bean)
+		}
+	}
+	return nil
+}
+
+// destroyBean runs every teardown step even if some fail, so resources
+// released by later steps don't leak, and returns the failures joined.
+func destroyBean(goCtx context.Context, bean *types.BeanContainer) error {
+	var errs []error
+	if beforeCloser, ok := bean.Bean.(types.BeforeCloser); ok {
+		err := beforeCloser.BeforeClose(goCtx)
+		if err != nil {
+			errs = append(errs, errors.WithMessage(err, "BeforeClose failed"))
+		}
+	}
+	for _, destroyFunc := range bean.DestroyFuncs {
+		err := destroyFunc(goCtx, bean.Bean)
+		if err != nil {
+			errs = append(errs, errors.WithMessage(err, "destroy func failed"))
+		}
+	}
+	if closeable, ok := bean.Bean.(io.Closer); ok {
+		err := closeable.Close()
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return stdErrors.Join(errs...)
+}
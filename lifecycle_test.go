@@ -0,0 +1,171 @@
+package yadi
+
+import (
+	"context"
+	g "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"github.com/xbl4de/yadi/types"
+	"testing"
+)
+
+var errInvalidLifecycleService = errors.New("invalid lifecycle service")
+
+type LifecycleService struct {
+	ServiceE *ServiceE
+	Events   []string `yadi:"ignore"`
+	Invalid  bool     `yadi:"ignore"`
+}
+
+func (s *LifecycleService) AfterInject() error {
+	s.Events = append(s.Events, "afterInject")
+	return nil
+}
+
+func (s *LifecycleService) Validate() error {
+	s.Events = append(s.Events, "validate")
+	if s.Invalid {
+		return errInvalidLifecycleService
+	}
+	return nil
+}
+
+func (s *LifecycleService) BeforeClose(ctx context.Context) error {
+	s.Events = append(s.Events, "beforeClose")
+	return nil
+}
+
+func (s *LifecycleService) Close() error {
+	s.Events = append(s.Events, "close")
+	return nil
+}
+
+func TestLifecycle_AutoBuiltBean_HooksCalled(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	ProvideDefaultValues()
+	UseLazyContext()
+
+	service, err := GetBean[*LifecycleService]()
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(service.ServiceE).ShouldNot(g.BeNil())
+	g.Expect(service.Events).Should(g.Equal([]string{"afterInject", "validate"}))
+
+	err = CloseContext()
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(service.Events).Should(g.Equal([]string{"afterInject", "validate", "beforeClose", "close"}))
+}
+
+func TestLifecycle_ProviderBean_ValidateFails(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	UseLazyContext()
+
+	SetBeanProvider[*LifecycleService](func(ctx types.Context) (*LifecycleService, error) {
+		return &LifecycleService{Invalid: true}, nil
+	})
+
+	_, err := GetBean[*LifecycleService]()
+	g.Expect(err).Should(g.MatchError(errInvalidLifecycleService))
+	g.Expect(err.Error()).Should(g.ContainSubstring("failed to init bean"))
+}
+
+func TestLifecycle_InitAndDestroyFuncs(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	UseLazyContext()
+
+	var events []string
+	SetBeanProviderFunc[*ServiceE](NewServiceE,
+		WithDefaultValueAt(0, "lifecycle"),
+		WithProviderOptions(
+			WithInitFunc(func(bean *ServiceE) error {
+				events = append(events, "init:"+bean.Description)
+				return nil
+			}),
+			WithDestroyFunc(func(ctx context.Context, bean *ServiceE) error {
+				events = append(events, "destroy:"+bean.Description)
+				return nil
+			}),
+		))
+
+	_, err := GetBean[*ServiceE]()
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(events).Should(g.Equal([]string{"init:lifecycle"}))
+
+	err = CloseContext()
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(events).Should(g.Equal([]string{"init:lifecycle", "destroy:lifecycle"}))
+}
+
+func TestLifecycle_InitFuncFails(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	UseLazyContext()
+	errInit := errors.New("errInit")
+
+	SetBeanProvider[*ServiceE](func(ctx types.Context) (*ServiceE, error) {
+		return NewServiceE("lifecycle"), nil
+	}, WithInitFunc(func(bean *ServiceE) error {
+		return errInit
+	}))
+
+	_, err := GetBean[*ServiceE]()
+	g.Expect(err).Should(g.MatchError(errInit))
+}
+
+func TestLifecycle_Inject_HooksCalled(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	ProvideDefaultValues()
+	UseLazyContext()
+
+	service := LifecycleService{}
+	err := Inject(&service)
+
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(service.Events).Should(g.Equal([]string{"afterInject", "validate"}))
+}
+
+var errBeforeClose = errors.New("before close failed")
+var errDestroy = errors.New("destroy failed")
+
+type FailingBeforeCloser struct {
+	Closed bool `yadi:"ignore"`
+}
+
+func (c *FailingBeforeCloser) BeforeClose(ctx context.Context) error {
+	return errBeforeClose
+}
+
+func (c *FailingBeforeCloser) Close() error {
+	c.Closed = true
+	return nil
+}
+
+func TestLifecycle_DestroyKeepsGoingAfterFailures(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	var events []string
+	SetBeanProvider[*FailingBeforeCloser](func(ctx types.Context) (*FailingBeforeCloser, error) {
+		return &FailingBeforeCloser{}, nil
+	},
+		WithDestroyFunc(func(ctx context.Context, bean *FailingBeforeCloser) error {
+			events = append(events, "first")
+			return errDestroy
+		}),
+		WithDestroyFunc(func(ctx context.Context, bean *FailingBeforeCloser) error {
+			events = append(events, "second")
+			return nil
+		}))
+	UseLazyContext()
+
+	closer, err := GetBean[*FailingBeforeCloser]()
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+
+	err = CloseContext()
+
+	g.Expect(err).Should(g.MatchError(errBeforeClose))
+	g.Expect(err).Should(g.MatchError(errDestroy))
+	g.Expect(events).Should(g.Equal([]string{"first", "second"}))
+	g.Expect(closer.Closed).Should(g.BeTrue())
+}
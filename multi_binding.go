@@ -0,0 +1,94 @@
+package yadi
+
+import (
+	"github.com/pkg/errors"
+	"github.com/xbl4de/yadi/types"
+	"github.com/xbl4de/yadi/utils"
+	"reflect"
+)
+
+// WithOrder sets the position of the bean in slices and maps of beans.
+// Beans with lower order come first, beans with the same order keep the
+// registration order.
+func WithOrder(order int) func(provider *types.BeanProvider) {
+	return func(provider *types.BeanProvider) {
+		provider.Order = order
+	}
+}
+
+func GetAll[T types.Bean](ctx types.Context) ([]T, error) {
+	beans, err := ctx.GetAll(reflect.TypeFor[T]())
+	if err != nil {
+		return nil, err
+	}
+	result := make([]T, 0, len(beans))
+	for _, bean := range beans {
+		casted, err := castBean[T](bean.Bean)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, casted)
+	}
+	return result, nil
+}
+
+func GetAllNamed[T types.Bean](ctx types.Context) (map[string]T, error) {
+	beans, err := getAllBeansValue(ctx, reflect.TypeFor[map[string]T]())
+	if err != nil {
+		return nil, err
+	}
+	return beans.(map[string]T), nil
+}
+
+// providerMatcher is implemented by the contexts of this package, so the
+// providers of a type are known without building the beans.
+type providerMatcher interface {
+	matchingProviders(typ reflect.Type) []*types.BeanProvider
+}
+
+// isMultiBeanType reports whether typ is a slice, or a map by bean name, of
+// pointers or interfaces having at least one provider. Other slices and maps,
+// e.g. of config structs, are read from values.
+func isMultiBeanType(ctx types.Context, typ reflect.Type) bool {
+	if typ.Kind() != reflect.Slice && (typ.Kind() != reflect.Map || typ.Key().Kind() != reflect.String) {
+		return false
+	}
+	elemType := typ.Elem()
+	switch {
+	case elemType.Kind() == reflect.Ptr && utils.IsTypeBean(elemType):
+	case elemType.Kind() == reflect.Interface && elemType.NumMethod() > 0:
+	default:
+		return false
+	}
+	for container, ok := ctx.(*Container); ok; container, ok = ctx.(*Container) {
+		ctx = container.Context
+	}
+	matcher, ok := ctx.(providerMatcher)
+	return !ok || len(matcher.matchingProviders(elemType)) > 0
+}
+
+// getAllBeansValue builds a slice of all beans of the element type, or a map
+// of them by bean name.
+func getAllBeansValue(ctx types.Context, typ reflect.Type) (interface{}, error) {
+	beans, err := ctx.GetAll(typ.Elem())
+	if err != nil {
+		return nil, err
+	}
+	if typ.Kind() == reflect.Slice {
+		slice := reflect.MakeSlice(typ, 0, len(beans))
+		for _, bean := range beans {
+			slice = reflect.Append(slice, reflect.ValueOf(bean.Bean))
+		}
+		return slice.Interface(), nil
+	}
+	beansMap := reflect.MakeMapWithSize(typ, len(beans))
+	for _, bean := range beans {
+		name := reflect.ValueOf(bean.Name).Convert(typ.Key())
+		if beansMap.MapIndex(name).IsValid() {
+			return nil, errors.Wrapf(types.ErrAmbiguousBean, "several beans of %s are named '%s'",
+				typ.Elem().String(), bean.Name)
+		}
+		beansMap.SetMapIndex(name, reflect.ValueOf(bean.Bean))
+	}
+	return beansMap.Interface(), nil
+}
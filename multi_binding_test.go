@@ -0,0 +1,196 @@
+package yadi
+
+import (
+	g "github.com/onsi/gomega"
+	"github.com/xbl4de/yadi/types"
+	"testing"
+)
+
+type Plugin interface {
+	PluginName() string
+}
+
+type NamedPlugin struct {
+	Name string `yadi:"ignore"`
+}
+
+func (p *NamedPlugin) PluginName() string {
+	return p.Name
+}
+
+type PluginRegistry struct {
+	Plugins       []Plugin
+	PluginsByName map[string]Plugin
+}
+
+func pluginNames(plugins []Plugin) []string {
+	names := make([]string, 0, len(plugins))
+	for _, plugin := range plugins {
+		names = append(names, plugin.PluginName())
+	}
+	return names
+}
+
+func newPluginContainer() *Container {
+	c := NewContainer()
+	for _, plugin := range []struct {
+		name  string
+		order int
+	}{
+		{"audit", 10},
+		{"auth", -1},
+		{"metrics", 10},
+	} {
+		name := plugin.name
+		_ = Provide(c, func(ctx types.Context) (*NamedPlugin, error) {
+			return &NamedPlugin{Name: name}, nil
+		}, WithBeanName(name), WithOrder(plugin.order))
+	}
+	return c
+}
+
+func TestGetAll_OrderedByOrderAndRegistration(t *testing.T) {
+	t.Parallel()
+	gt := g.NewWithT(t)
+	c := newPluginContainer()
+
+	plugins, err := GetAll[Plugin](c)
+
+	gt.Expect(err).ShouldNot(g.HaveOccurred())
+	gt.Expect(pluginNames(plugins)).Should(g.Equal([]string{"auth", "audit", "metrics"}))
+	gt.Expect(plugins[0]).Should(g.BeIdenticalTo(RequireNamed[*NamedPlugin](c, "auth")))
+}
+
+func TestGetAllNamed(t *testing.T) {
+	t.Parallel()
+	gt := g.NewWithT(t)
+	c := newPluginContainer()
+
+	plugins, err := GetAllNamed[*NamedPlugin](c)
+
+	gt.Expect(err).ShouldNot(g.HaveOccurred())
+	gt.Expect(plugins).Should(g.HaveLen(3))
+	gt.Expect(plugins["metrics"].Name).Should(g.Equal("metrics"))
+}
+
+func TestMultiBinding_FieldInjection(t *testing.T) {
+	t.Parallel()
+	gt := g.NewWithT(t)
+	c := newPluginContainer()
+
+	registry, err := Get[*PluginRegistry](c)
+
+	gt.Expect(err).ShouldNot(g.HaveOccurred())
+	gt.Expect(pluginNames(registry.Plugins)).Should(g.Equal([]string{"auth", "audit", "metrics"}))
+	gt.Expect(registry.PluginsByName).Should(g.HaveKey("audit"))
+	gt.Expect(registry.PluginsByName["audit"].PluginName()).Should(g.Equal("audit"))
+}
+
+func TestMultiBinding_FuncProviderParameter(t *testing.T) {
+	t.Parallel()
+	gt := g.NewWithT(t)
+	c := newPluginContainer()
+	gt.Expect(ProvideFunc[*PluginRegistry](c, func(plugins []Plugin) *PluginRegistry {
+		return &PluginRegistry{Plugins: plugins}
+	})).Should(g.Succeed())
+
+	registry, err := Get[*PluginRegistry](c)
+
+	gt.Expect(err).ShouldNot(g.HaveOccurred())
+	gt.Expect(pluginNames(registry.Plugins)).Should(g.Equal([]string{"auth", "audit", "metrics"}))
+}
+
+type OptionalPluginRegistry struct {
+	Plugins       []Plugin          `yadi:"optional"`
+	PluginsByName map[string]Plugin `yadi:"optional"`
+}
+
+func TestMultiBinding_NoBeans(t *testing.T) {
+	t.Parallel()
+	gt := g.NewWithT(t)
+	c := NewContainer()
+
+	_, err := Get[*PluginRegistry](c)
+	gt.Expect(err).Should(g.MatchError(types.ErrNoValueFound))
+
+	registry, err := Get[*OptionalPluginRegistry](c)
+	gt.Expect(err).ShouldNot(g.HaveOccurred())
+	gt.Expect(registry.Plugins).Should(g.BeEmpty())
+	gt.Expect(registry.PluginsByName).Should(g.BeEmpty())
+}
+
+type ReplicaHolder struct {
+	Replicas []ReplicaConfig        `yadi:"optional"`
+	Extras   map[string]interface{} `yadi:"optional"`
+}
+
+func TestMultiBinding_StructAndEmptyInterfaceElementsAreValues(t *testing.T) {
+	t.Parallel()
+	gt := g.NewWithT(t)
+	c := NewContainer()
+	gt.Expect(ProvideFunc[ReplicaConfig](c, func() ReplicaConfig {
+		return ReplicaConfig{Host: "bean"}
+	})).Should(g.Succeed())
+
+	holder, err := Get[*ReplicaHolder](c)
+
+	gt.Expect(err).ShouldNot(g.HaveOccurred())
+	gt.Expect(holder.Replicas).Should(g.BeEmpty())
+	gt.Expect(holder.Extras).Should(g.BeEmpty())
+}
+
+func TestMultiBinding_DuplicateNames(t *testing.T) {
+	t.Parallel()
+	gt := g.NewWithT(t)
+	c := NewContainer()
+	gt.Expect(ProvideFunc[*NamedPlugin](c, func() *NamedPlugin {
+		return &NamedPlugin{}
+	})).Should(g.Succeed())
+	gt.Expect(ProvideFunc[*MessageStore](c, func() *MessageStore {
+		return &MessageStore{}
+	})).Should(g.Succeed())
+
+	_, err := GetAllNamed[interface{}](c)
+
+	gt.Expect(err).Should(g.MatchError(types.ErrAmbiguousBean))
+}
+
+func TestGetBeans_Global(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	SetBeanProvider[*NamedPlugin](func(ctx types.Context) (*NamedPlugin, error) {
+		return &NamedPlugin{Name: "second"}, nil
+	}, WithBeanName("second"), WithOrder(2))
+	SetBeanProvider[*NamedPlugin](func(ctx types.Context) (*NamedPlugin, error) {
+		return &NamedPlugin{Name: "first"}, nil
+	}, WithBeanName("first"), WithOrder(1))
+	UseLazyContext()
+
+	plugins, err := GetBeans[Plugin]()
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(pluginNames(plugins)).Should(g.Equal([]string{"first", "second"}))
+
+	named, err := GetNamedBeans[Plugin]()
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(named).Should(g.HaveLen(2))
+	g.Expect(named["second"].PluginName()).Should(g.Equal("second"))
+}
+
+type NamedPluginList struct {
+	Plugins []*NamedPlugin
+}
+
+func TestContainer_Inject_NoBeans(t *testing.T) {
+	t.Parallel()
+	gt := g.NewWithT(t)
+	c := NewContainer()
+
+	var list NamedPluginList
+	err := c.Inject(&list)
+
+	gt.Expect(err).Should(g.MatchError(types.ErrNoValueFound))
+
+	c = newPluginContainer()
+	gt.Expect(c.Inject(&list)).Should(g.Succeed())
+	gt.Expect(list.Plugins).Should(g.HaveLen(3))
+}
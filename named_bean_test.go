@@ -259,3 +259,47 @@ func TestGetNamedBeanOrDefault_NilContext(t *testing.T) {
 	g.Expect(bean).ShouldNot(g.BeNil())
 	g.Expect(bean.Description).Should(g.Equal("def"))
 }
+
+type NamedBeanHolder struct {
+	Primary   *ServiceE `yadi:"beanName=primaryE"`
+	Secondary *ServiceE `yadi:"beanName=secondaryE"`
+	Unnamed   *ServiceE
+}
+
+type MissingNamedBeanHolder struct {
+	ServiceE *ServiceE `yadi:"beanName=missingE"`
+}
+
+func TestInject_BeanNameTag(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	ProvideDefaultValues()
+	UseLazyContext()
+
+	SetBeanProviderFunc[*ServiceE](NewServiceE,
+		WithDefaultValueAt(0, "primary"),
+		WithFuncProviderBeanName("primaryE"))
+	SetBeanProviderFunc[*ServiceE](NewServiceE,
+		WithDefaultValueAt(0, "secondary"),
+		WithFuncProviderBeanName("secondaryE"))
+
+	holder, err := GetBean[*NamedBeanHolder]()
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(holder.Primary.Description).Should(g.Equal("primary"))
+	g.Expect(holder.Secondary.Description).Should(g.Equal("secondary"))
+	g.Expect(holder.Unnamed.Description).Should(g.Equal(ServiceEDescription))
+	g.Expect(holder.Primary).Should(g.BeIdenticalTo(RequireNamedBean[*ServiceE]("primaryE")))
+}
+
+func TestInject_BeanNameTag_NoSuchBean(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	ProvideDefaultValues()
+	UseLazyContext()
+
+	_, err := GetBean[*MissingNamedBeanHolder]()
+
+	g.Expect(err).Should(g.MatchError(types.ErrNoBeanProvider))
+	g.Expect(err.Error()).Should(g.ContainSubstring("missingE"))
+	g.Expect(err.Error()).Should(g.ContainSubstring("field ServiceE"))
+}
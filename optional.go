@@ -0,0 +1,58 @@
+package yadi
+
+import (
+	"github.com/pkg/errors"
+	"github.com/xbl4de/yadi/types"
+	"reflect"
+)
+
+var optionalValueType = reflect.TypeFor[types.OptionalValue]()
+
+func isOptionalType(typ reflect.Type) bool {
+	return typ.Kind() == reflect.Struct && reflect.PointerTo(typ).Implements(optionalValueType)
+}
+
+// newOptional builds a types.Optional of typ with the value returned by
+// resolve. The optional is left empty if there is nothing to inject.
+func newOptional(typ reflect.Type, resolve func(valueType reflect.Type) (interface{}, error)) (interface{}, error) {
+	optional := reflect.New(typ)
+	holder := optional.Interface().(types.OptionalValue)
+	value, err := resolve(holder.ValueType())
+	if err != nil {
+		if isMissingDependency(err) {
+			return optional.Elem().Interface(), nil
+		}
+		return nil, err
+	}
+	holder.SetValue(value)
+	return optional.Elem().Interface(), nil
+}
+
+// buildError is a failure of a dependency that exists, e.g. a provider
+// returning an error or a section holding an invalid value. Such a dependency
+// is not missing even if something it needs is.
+type buildError struct {
+	err error
+}
+
+func (e *buildError) Error() string {
+	return e.err.Error()
+}
+
+func (e *buildError) Unwrap() error {
+	return e.err
+}
+
+func newBuildError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &buildError{err: err}
+}
+
+// isMissingDependency reports whether err tells that nothing provides the
+// dependency itself, so an optional one is left empty.
+func isMissingDependency(err error) bool {
+	var buildErr *buildError
+	return types.ErrNoInjectableProvided(err) && !errors.As(err, &buildErr)
+}
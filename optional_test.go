@@ -0,0 +1,181 @@
+package yadi
+
+import (
+	g "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"github.com/xbl4de/yadi/types"
+	"testing"
+)
+
+type AuditLog interface {
+	Record(event string)
+}
+
+type ConsoleAuditLog struct{}
+
+func (l *ConsoleAuditLog) Record(string) {}
+
+type OptionalConsumer struct {
+	Audit   AuditLog                 `yadi:"optional"`
+	Timeout int                      `yadi:"optional;path=consumer.timeout"`
+	Retries types.Optional[int]      `yadi:"path=consumer.retries"`
+	Log     types.Optional[AuditLog] `yadi:""`
+}
+
+func NewOptionalConsumer(audit AuditLog, retries types.Optional[int]) *OptionalConsumer {
+	return &OptionalConsumer{
+		Audit:   audit,
+		Retries: retries,
+	}
+}
+
+func TestOptional_MissingDependencies_LeftEmpty(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	UseLazyContext()
+
+	consumer, err := GetBean[*OptionalConsumer]()
+
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(consumer.Audit).Should(g.BeNil())
+	g.Expect(consumer.Timeout).Should(g.BeZero())
+	_, ok := consumer.Retries.Get()
+	g.Expect(ok).Should(g.BeFalse())
+	_, ok = consumer.Log.Get()
+	g.Expect(ok).Should(g.BeFalse())
+	g.Expect(consumer.Retries.OrElse(3)).Should(g.Equal(3))
+}
+
+func TestOptional_PresentDependencies_Injected(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	SetBeanProvider[AuditLog](func(ctx types.Context) (AuditLog, error) {
+		return &ConsoleAuditLog{}, nil
+	})
+	SetValue("consumer.timeout", 30)
+	SetValue("consumer.retries", 5)
+	UseLazyContext()
+
+	consumer, err := GetBean[*OptionalConsumer]()
+
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(consumer.Audit).Should(g.BeAssignableToTypeOf(&ConsoleAuditLog{}))
+	g.Expect(consumer.Timeout).Should(g.Equal(30))
+	retries, ok := consumer.Retries.Get()
+	g.Expect(ok).Should(g.BeTrue())
+	g.Expect(retries).Should(g.Equal(5))
+	log, ok := consumer.Log.Get()
+	g.Expect(ok).Should(g.BeTrue())
+	g.Expect(log).Should(g.BeIdenticalTo(consumer.Audit))
+}
+
+func TestOptional_BuildFailure_NotHidden(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	errAudit := errors.New("errAudit")
+	SetBeanProvider[AuditLog](func(ctx types.Context) (AuditLog, error) {
+		return nil, errAudit
+	})
+	UseLazyContext()
+
+	_, err := GetBean[*OptionalConsumer]()
+
+	g.Expect(err).Should(g.MatchError(errAudit))
+}
+
+func TestSetBeanProviderFunc_WithOptionalAt(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	SetBeanProviderFunc[*OptionalConsumer](NewOptionalConsumer,
+		WithOptionalAt(0),
+		WithValuePathAt(1, "consumer.retries"))
+	UseLazyContext()
+
+	consumer, err := GetBean[*OptionalConsumer]()
+
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(consumer.Audit).Should(g.BeNil())
+	_, ok := consumer.Retries.Get()
+	g.Expect(ok).Should(g.BeFalse())
+}
+
+func TestSetBeanProviderFunc_WithoutOptionalAt_Fails(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	SetBeanProviderFunc[*OptionalConsumer](NewOptionalConsumer,
+		WithValuePathAt(1, "consumer.retries"))
+	UseLazyContext()
+
+	_, err := GetBean[*OptionalConsumer]()
+
+	g.Expect(err).Should(g.MatchError(types.ErrNoBeanProvider))
+}
+
+type MissingDependency struct{}
+
+type FailingDependency struct {
+	Missing *MissingDependency
+}
+
+type OptionalFailingConsumer struct {
+	Failing *FailingDependency `yadi:"optional"`
+}
+
+func TestOptional_TransitiveMissingDependency_NotHidden(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	SetBeanProvider[*FailingDependency](func(ctx types.Context) (*FailingDependency, error) {
+		missing, err := GetNamed[*MissingDependency](ctx, "missing")
+		if err != nil {
+			return nil, err
+		}
+		return &FailingDependency{Missing: missing}, nil
+	})
+	SetBeanProviderFunc[*OptionalConsumer](func(failing types.Optional[*FailingDependency]) *OptionalConsumer {
+		return &OptionalConsumer{}
+	})
+	UseLazyContext()
+
+	_, err := GetBean[*OptionalFailingConsumer]()
+	g.Expect(err).Should(g.MatchError(types.ErrNoBeanProvider))
+	g.Expect(err.Error()).Should(g.ContainSubstring("missing[*yadi.MissingDependency]"))
+
+	_, err = GetBean[*OptionalConsumer]()
+	g.Expect(err).Should(g.MatchError(types.ErrNoBeanProvider))
+}
+
+type PoolHolder struct {
+	Pool *PoolConfig `yadi:"optional;path=pool"`
+}
+
+type AutoBuiltPool struct {
+	Pool PoolConfig `yadi:"path=pool"`
+}
+
+type OptionalAutoBuiltConsumer struct {
+	Pool types.Optional[*AutoBuiltPool]
+}
+
+func TestOptional_PartialValues_NotHidden(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	SetValue("pool.timeout", "1s")
+	UseLazyContext()
+
+	_, err := GetBean[*PoolHolder]()
+	g.Expect(err).Should(g.MatchError(types.ErrNoValueFound))
+
+	_, err = GetBean[*OptionalAutoBuiltConsumer]()
+	g.Expect(err).Should(g.MatchError(types.ErrNoValueFound))
+}
+
+func TestOptional_MissingSection_LeftEmpty(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	UseLazyContext()
+
+	holder, err := GetBean[*PoolHolder]()
+
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(holder.Pool).Should(g.BeNil())
+}
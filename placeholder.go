@@ -0,0 +1,145 @@
+package yadi
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/xbl4de/yadi/types"
+	"os"
+	"slices"
+	"strings"
+)
+
+// placeholderResolver replaces ${path} and ${path:default} in string values
+// with other values or environment variables. chain holds the paths being
+// resolved to detect self references.
+type placeholderResolver struct {
+	ctx   types.Context
+	chain []string
+}
+
+// resolvePlaceholders resolves the placeholders in the value of path,
+// including the strings nested in maps and lists.
+func resolvePlaceholders(ctx types.Context, path string, value interface{}) (interface{}, error) {
+	resolver := &placeholderResolver{ctx: ctx, chain: []string{path}}
+	return resolver.resolve(value)
+}
+
+func (r *placeholderResolver) resolve(value interface{}) (interface{}, error) {
+	switch typed := value.(type) {
+	case string:
+		return r.resolveString(typed)
+	case map[string]interface{}:
+		resolved := make(map[string]interface{}, len(typed))
+		for key, item := range typed {
+			resolvedItem, err := r.resolve(item)
+			if err != nil {
+				return nil, err
+			}
+			resolved[key] = resolvedItem
+		}
+		return resolved, nil
+	case []interface{}:
+		resolved := make([]interface{}, len(typed))
+		for i, item := range typed {
+			resolvedItem, err := r.resolve(item)
+			if err != nil {
+				return nil, err
+			}
+			resolved[i] = resolvedItem
+		}
+		return resolved, nil
+	default:
+		return value, nil
+	}
+}
+
+// resolveString keeps the type of the referenced value if the string is a
+// single placeholder, otherwise the values are formatted into the string.
+// $${ is written as ${.
+func (r *placeholderResolver) resolveString(value string) (interface{}, error) {
+	if !strings.Contains(value, "${") {
+		return value, nil
+	}
+	builder := strings.Builder{}
+	for i := 0; i < len(value); {
+		if strings.HasPrefix(value[i:], "$${") {
+			builder.WriteString("${")
+			i += 3
+			continue
+		}
+		if !strings.HasPrefix(value[i:], "${") {
+			builder.WriteByte(value[i])
+			i++
+			continue
+		}
+		end := placeholderEnd(value, i+2)
+		if end < 0 {
+			return nil, fmt.Errorf("%w: unterminated placeholder in %q of %s",
+				types.ErrUnresolvedPlaceholder, value, r.formatChain())
+		}
+		resolved, err := r.resolvePlaceholder(value[i+2 : end])
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 && end == len(value)-1 {
+			return resolved, nil
+		}
+		builder.WriteString(fmt.Sprint(resolved))
+		i = end + 1
+	}
+	return builder.String(), nil
+}
+
+// placeholderEnd returns the index of the brace closing the placeholder
+// started before start, skipping placeholders nested in its default.
+func placeholderEnd(value string, start int) int {
+	depth := 0
+	for i := start; i < len(value); i++ {
+		switch {
+		case strings.HasPrefix(value[i:], "${"):
+			depth++
+			i++
+		case value[i] == '}' && depth == 0:
+			return i
+		case value[i] == '}':
+			depth--
+		}
+	}
+	return -1
+}
+
+// resolvePlaceholder reads the value of path, then the environment variable
+// named path, then the default.
+func (r *placeholderResolver) resolvePlaceholder(placeholder string) (interface{}, error) {
+	path, defaultValue, hasDefault := strings.Cut(placeholder, ":")
+	if path == "" {
+		return nil, fmt.Errorf("%w: empty placeholder in %s", types.ErrUnresolvedPlaceholder, r.formatChain())
+	}
+	if slices.Contains(r.chain, path) {
+		return nil, fmt.Errorf("%w: placeholder refers to itself\n%s", types.ErrCycleDependencies, r.formatChainTo(path))
+	}
+	value, err := r.ctx.GetGenericValue(path)
+	if err == nil {
+		nested := &placeholderResolver{ctx: r.ctx, chain: append(slices.Clip(r.chain), path)}
+		return nested.resolve(value)
+	}
+	if !errors.Is(err, types.ErrNoValueFound) {
+		return nil, err
+	}
+	if env, ok := os.LookupEnv(path); ok {
+		return env, nil
+	}
+	if hasDefault {
+		return r.resolveString(defaultValue)
+	}
+	return nil, fmt.Errorf("%w: no value or environment variable for ${%s}\n%s",
+		types.ErrUnresolvedPlaceholder, path, r.formatChainTo(path))
+}
+
+func (r *placeholderResolver) formatChain() string {
+	return strings.Join(r.chain, " -> ")
+}
+
+func (r *placeholderResolver) formatChainTo(path string) string {
+	return r.formatChain() + " -> " + path
+}
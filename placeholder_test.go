@@ -0,0 +1,163 @@
+package yadi
+
+import (
+	g "github.com/onsi/gomega"
+	"github.com/xbl4de/yadi/types"
+	"strings"
+	"testing"
+)
+
+type PlaceholderConfig struct {
+	URL  string `yadi:"path=db.url"`
+	Port int    `yadi:"path=db.connectPort"`
+}
+
+func TestPlaceholder_Interpolation(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	t.Setenv("YADITEST_DB_NAME", "orders")
+	SetValue("db.host", "localhost")
+	SetValue("db.url", "postgres://${db.host}:${db.port:5432}/${YADITEST_DB_NAME}")
+	UseLazyContext()
+
+	url, err := GetValue[string]("db.url")
+
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(url).Should(g.Equal("postgres://localhost:5432/orders"))
+}
+
+func TestPlaceholder_ResolvedOnRead(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	SetValue("db.url", "postgres://${db.host}")
+	UseLazyContext()
+
+	SetValue("db.host", "example.com")
+
+	g.Expect(GetValue[string]("db.url")).Should(g.Equal("postgres://example.com"))
+}
+
+func TestPlaceholder_SinglePlaceholderKeepsType(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	SetValue("db.port", 5433)
+	SetValue("db.connectPort", "${db.port}")
+	SetValue("db.url", "postgres://localhost:${db.port}")
+	UseLazyContext()
+
+	config, err := GetBean[PlaceholderConfig]()
+
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(config.Port).Should(g.Equal(5433))
+	g.Expect(config.URL).Should(g.Equal("postgres://localhost:5433"))
+}
+
+func TestPlaceholder_NestedDefault(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	SetValue("fallback", "backup")
+	SetValue("host", "${primary:${secondary:${fallback}}}")
+	UseLazyContext()
+
+	g.Expect(GetValue[string]("host")).Should(g.Equal("backup"))
+}
+
+func TestPlaceholder_Chain(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	err := LoadValues(YAMLSource("config.yaml", strings.NewReader(`
+app:
+  name: shop
+  title: "${app.name} v${app.version}"
+  version: "1.${app.minor}"
+  minor: 2
+`)))
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	UseLazyContext()
+
+	g.Expect(GetValue[string]("app.title")).Should(g.Equal("shop v1.2"))
+}
+
+func TestPlaceholder_InCollectionsAndSections(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	SetValue("base", "localhost")
+	SetValue("urls", []any{"http://${base}/a", "http://${base}/b"})
+	SetValue("db", map[string]any{
+		"host": "${base}",
+		"port": "${db.pool.size}0",
+		"user": "${YADITEST_USER_NAME:admin}",
+		"pool": map[string]any{"size": "${pool:10}"},
+	})
+	UseLazyContext()
+
+	g.Expect(GetValue[[]string]("urls")).Should(g.Equal([]string{"http://localhost/a", "http://localhost/b"}))
+
+	db, err := BindValues[DatabaseConfig]("db")
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(db.Host).Should(g.Equal("localhost"))
+	g.Expect(db.Port).Should(g.Equal(100))
+	g.Expect(db.UserName).Should(g.Equal("admin"))
+	g.Expect(db.Pool.Size).Should(g.Equal(10))
+}
+
+func TestPlaceholder_Escaped(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	SetValue("template", "$${name} is ${name:unknown}")
+	UseLazyContext()
+
+	g.Expect(GetValue[string]("template")).Should(g.Equal("${name} is unknown"))
+}
+
+func TestPlaceholder_Unresolvable(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	SetValue("db.url", "postgres://${db.host}")
+	SetValue("app.dsn", "${db.url}")
+	UseLazyContext()
+
+	_, err := GetValue[string]("app.dsn")
+
+	g.Expect(err).Should(g.MatchError(types.ErrUnresolvedPlaceholder))
+	g.Expect(err.Error()).Should(g.ContainSubstring("app.dsn -> db.url -> db.host"))
+}
+
+func TestPlaceholder_Unterminated(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	SetValue("db.url", "postgres://${db.host")
+	UseLazyContext()
+
+	_, err := GetValue[string]("db.url")
+
+	g.Expect(err).Should(g.MatchError(types.ErrUnresolvedPlaceholder))
+}
+
+func TestPlaceholder_Cycle(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	SetValue("a", "${b}")
+	SetValue("b", "x-${c:${a}}")
+	SetValue("self", "${self}")
+	UseLazyContext()
+
+	_, err := GetValue[string]("a")
+	g.Expect(err).Should(g.MatchError(types.ErrCycleDependencies))
+	g.Expect(err.Error()).Should(g.ContainSubstring("a -> b -> a"))
+
+	_, err = GetValue[string]("self")
+	g.Expect(err).Should(g.MatchError(types.ErrCycleDependencies))
+}
+
+func TestPlaceholder_InjectionError(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	SetValue("db.url", "${missing}")
+	SetValue("db.connectPort", 1)
+	UseLazyContext()
+
+	_, err := GetBean[PlaceholderConfig]()
+
+	g.Expect(err).Should(g.MatchError(types.ErrUnresolvedPlaceholder))
+}
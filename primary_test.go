@@ -0,0 +1,146 @@
+package yadi
+
+import (
+	g "github.com/onsi/gomega"
+	"github.com/xbl4de/yadi/types"
+	"testing"
+)
+
+func TestWithPrimary_WinsOverUnnamedBean(t *testing.T) {
+	t.Parallel()
+	gt := g.NewWithT(t)
+	c := newTestContainer()
+	gt.Expect(ProvideFunc[*ServiceE](c, NewServiceE, WithDefaultValueAt(0, "unnamed"))).Should(g.Succeed())
+	gt.Expect(ProvideFunc[*ServiceE](c, NewServiceE,
+		WithDefaultValueAt(0, "primary"),
+		WithFuncProviderBeanName("override"),
+		WithProviderOptions(WithPrimary()))).Should(g.Succeed())
+
+	serviceE, err := Get[*ServiceE](c)
+	gt.Expect(err).ShouldNot(g.HaveOccurred())
+	gt.Expect(serviceE.Description).Should(g.Equal("primary"))
+	gt.Expect(serviceE).Should(g.BeIdenticalTo(RequireNamed[*ServiceE](c, "override")))
+
+	serviceA, err := Get[*ServiceA](c)
+	gt.Expect(err).ShouldNot(g.HaveOccurred())
+	gt.Expect(serviceA.ServiceE).Should(g.BeIdenticalTo(serviceE))
+}
+
+func TestWithPrimary_ResolvesInterfaceAmbiguity(t *testing.T) {
+	t.Parallel()
+	gt := g.NewWithT(t)
+	c := NewContainer()
+	gt.Expect(ProvideFunc[*MessageStore](c, func() *MessageStore {
+		return &MessageStore{Message: "default"}
+	})).Should(g.Succeed())
+	gt.Expect(ProvideFunc[*OtherMessageStore](c, func() *OtherMessageStore {
+		return &OtherMessageStore{}
+	}, WithProviderOptions(WithPrimary()))).Should(g.Succeed())
+
+	reader, err := Get[MessageReader](c)
+
+	gt.Expect(err).ShouldNot(g.HaveOccurred())
+	gt.Expect(reader.Read()).Should(g.Equal("other"))
+}
+
+func TestWithPrimary_SeveralPrimaryImplementations(t *testing.T) {
+	t.Parallel()
+	gt := g.NewWithT(t)
+	c := NewContainer()
+	gt.Expect(ProvideFunc[*MessageStore](c, func() *MessageStore {
+		return &MessageStore{}
+	}, WithProviderOptions(WithPrimary()))).Should(g.Succeed())
+	gt.Expect(ProvideFunc[*OtherMessageStore](c, func() *OtherMessageStore {
+		return &OtherMessageStore{}
+	}, WithProviderOptions(WithPrimary()))).Should(g.Succeed())
+
+	_, err := Get[MessageReader](c)
+
+	gt.Expect(err).Should(g.MatchError(types.ErrMultiplePrimaryBeans))
+}
+
+func TestWithPrimary_Collision(t *testing.T) {
+	t.Parallel()
+	gt := g.NewWithT(t)
+	c := NewContainer()
+	gt.Expect(ProvideFunc[*ServiceE](c, NewServiceE,
+		WithFuncProviderBeanName("first"),
+		WithProviderOptions(WithPrimary()))).Should(g.Succeed())
+
+	err := ProvideFunc[*ServiceE](c, NewServiceE,
+		WithFuncProviderBeanName("second"),
+		WithProviderOptions(WithPrimary()))
+
+	gt.Expect(err).Should(g.MatchError(types.ErrMultiplePrimaryBeans))
+	gt.Expect(err.Error()).Should(g.ContainSubstring("first[*yadi.ServiceE]"))
+}
+
+func TestWithPrimary_ChildOverridesParentPrimary(t *testing.T) {
+	t.Parallel()
+	gt := g.NewWithT(t)
+	parent := NewContainer()
+	gt.Expect(ProvideFunc[*ServiceE](parent, NewServiceE,
+		WithDefaultValueAt(0, "parent"),
+		WithFuncProviderBeanName("parent"),
+		WithProviderOptions(WithPrimary()))).Should(g.Succeed())
+	child := parent.Child()
+	gt.Expect(ProvideFunc[*ServiceE](child, NewServiceE,
+		WithDefaultValueAt(0, "child"),
+		WithFuncProviderBeanName("child"),
+		WithProviderOptions(WithPrimary()))).Should(g.Succeed())
+
+	gt.Expect(Require[*ServiceE](child).Description).Should(g.Equal("child"))
+	gt.Expect(Require[*ServiceE](parent).Description).Should(g.Equal("parent"))
+}
+
+type NamedPluginHolder struct {
+	Plugins []*NamedPlugin
+}
+
+func TestWithPrimary_MultiBindingKeepsUnnamedBean(t *testing.T) {
+	t.Parallel()
+	gt := g.NewWithT(t)
+	c := NewContainer()
+	gt.Expect(Provide(c, func(ctx types.Context) (*NamedPlugin, error) {
+		return &NamedPlugin{Name: "default"}, nil
+	})).Should(g.Succeed())
+	gt.Expect(Provide(c, func(ctx types.Context) (*NamedPlugin, error) {
+		return &NamedPlugin{Name: "fast"}, nil
+	}, WithBeanName("fast"), WithPrimary())).Should(g.Succeed())
+
+	plugins, err := GetAll[*NamedPlugin](c)
+	gt.Expect(err).ShouldNot(g.HaveOccurred())
+	gt.Expect(pluginNames([]Plugin{plugins[0], plugins[1]})).Should(g.Equal([]string{"default", "fast"}))
+
+	byName, err := GetAllNamed[*NamedPlugin](c)
+	gt.Expect(err).ShouldNot(g.HaveOccurred())
+	gt.Expect(byName[""].Name).Should(g.Equal("default"))
+	gt.Expect(byName["fast"].Name).Should(g.Equal("fast"))
+
+	holder, err := Get[*NamedPluginHolder](c)
+	gt.Expect(err).ShouldNot(g.HaveOccurred())
+	gt.Expect(holder.Plugins).Should(g.HaveLen(2))
+	gt.Expect(holder.Plugins[0].Name).Should(g.Equal("default"))
+
+	gt.Expect(Require[*NamedPlugin](c).Name).Should(g.Equal("fast"))
+}
+
+func TestWithPrimary_ReplacedByNonPrimaryProvider(t *testing.T) {
+	t.Parallel()
+	gt := g.NewWithT(t)
+	c := NewContainer()
+	gt.Expect(ProvideFunc[*ServiceE](c, NewServiceE, WithDefaultValueAt(0, "unnamed"))).Should(g.Succeed())
+	gt.Expect(ProvideFunc[*ServiceE](c, NewServiceE,
+		WithDefaultValueAt(0, "first"),
+		WithFuncProviderBeanName("first"),
+		WithProviderOptions(WithPrimary()))).Should(g.Succeed())
+	gt.Expect(ProvideFunc[*ServiceE](c, NewServiceE,
+		WithDefaultValueAt(0, "first"),
+		WithFuncProviderBeanName("first"))).Should(g.Succeed())
+	gt.Expect(ProvideFunc[*ServiceE](c, NewServiceE,
+		WithDefaultValueAt(0, "second"),
+		WithFuncProviderBeanName("second"),
+		WithProviderOptions(WithPrimary()))).Should(g.Succeed())
+
+	gt.Expect(Require[*ServiceE](c).Description).Should(g.Equal("second"))
+}
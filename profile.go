@@ -0,0 +1,172 @@
+package yadi
+
+import (
+	"github.com/pkg/errors"
+	"github.com/xbl4de/yadi/types"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync/atomic"
+)
+
+// ActiveProfilesPath is the value path holding the active profiles, a list or
+// a comma-separated string.
+const ActiveProfilesPath = "yadi.profiles.active"
+
+// ActiveProfilesEnv is the environment variable read for ActiveProfilesPath.
+const ActiveProfilesEnv = "YADI_PROFILES_ACTIVE"
+
+// WithProfile makes the provider used only while the profile is active. It
+// takes precedence over the provider of the same bean without a profile.
+func WithProfile(profile string) func(provider *types.BeanProvider) {
+	return func(provider *types.BeanProvider) {
+		provider.Profile = profile
+	}
+}
+
+// ActiveProfiles returns the profiles read from ActiveProfilesPath.
+func ActiveProfiles(ctx types.Context) ([]string, error) {
+	value, err := getTypedValue(ctx, ActiveProfilesPath, reflect.TypeFor[[]string]())
+	if err != nil {
+		if errors.Is(err, types.ErrNoValueFound) {
+			return nil, nil
+		}
+		return nil, errors.WithMessage(err, "cannot read active profiles")
+	}
+	profiles := make([]string, 0)
+	for _, profile := range value.([]string) {
+		if profile = strings.TrimSpace(profile); profile != "" {
+			profiles = append(profiles, profile)
+		}
+	}
+	return profiles, nil
+}
+
+// valuesVersion changes whenever a value of any context is set, deleted or
+// may be resolved by a new lookup.
+var valuesVersion atomic.Uint64
+
+// profilesCache holds the active profiles read while valuesVersion was
+// version.
+type profilesCache struct {
+	version  uint64
+	profiles []string
+}
+
+// activeProfiles is ActiveProfiles ignoring invalid values, it must not be
+// called while holding ctx.mu. The profiles are read again only after values
+// change, so lookups reading the environment are not asked on every bean
+// lookup.
+func (ctx *LazyContext) activeProfiles() []string {
+	version := valuesVersion.Load()
+	if cached := ctx.profiles.Load(); cached != nil && cached.version == version {
+		return cached.profiles
+	}
+	profiles, _ := ActiveProfiles(ctx)
+	ctx.profiles.Store(&profilesCache{version: version, profiles: profiles})
+	return profiles
+}
+
+// activeProvider returns the provider of the last active profile having one,
+// or the provider without a profile.
+func activeProvider(byProfile map[string]*types.BeanProvider, profiles []string) *types.BeanProvider {
+	for i := len(profiles) - 1; i >= 0; i-- {
+		if provider, ok := byProfile[profiles[i]]; ok {
+			return provider
+		}
+	}
+	return byProfile[""]
+}
+
+// profilesEnvLookup resolves ActiveProfilesPath from ActiveProfilesEnv.
+type profilesEnvLookup struct{}
+
+func (profilesEnvLookup) Name() string {
+	return "env"
+}
+
+func (profilesEnvLookup) Layer() types.ValueLayer {
+	return types.EnvLayer
+}
+
+func (profilesEnvLookup) Locate(string) string {
+	return ActiveProfilesEnv
+}
+
+func (profilesEnvLookup) Lookup(path string) (interface{}, bool) {
+	if path != ActiveProfilesPath {
+		return nil, false
+	}
+	value, ok := os.LookupEnv(ActiveProfilesEnv)
+	if !ok {
+		return nil, false
+	}
+	return value, true
+}
+
+func (l profilesEnvLookup) Load() (map[string]interface{}, error) {
+	values := make(map[string]interface{})
+	if value, ok := l.Lookup(ActiveProfilesPath); ok {
+		values[ActiveProfilesPath] = value
+	}
+	return values, nil
+}
+
+// profileSource is the overlay of a file for a profile, loaded into
+// types.ProfileLayer. A missing overlay has no values.
+type profileSource struct {
+	*documentSource
+}
+
+func (s profileSource) Layer() types.ValueLayer {
+	return types.ProfileLayer
+}
+
+func (s profileSource) Load() (map[string]interface{}, error) {
+	values, err := s.documentSource.Load()
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]interface{}{}, nil
+	}
+	return values, err
+}
+
+// ProfileFilePath returns the overlay of path for the profile, e.g.
+// config-prod.yaml for config.yaml.
+func ProfileFilePath(path string, profile string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "-" + profile + ext
+}
+
+// profileSources returns the overlays of path for the active profiles in
+// their order, so later profiles override earlier ones.
+func profileSources(ctx types.Context, path string, fsys fs.FS) ([]types.ValueSource, error) {
+	profiles, err := ActiveProfiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sources := make([]types.ValueSource, 0, len(profiles))
+	for _, profile := range slices.Compact(profiles) {
+		profilePath := ProfileFilePath(path, profile)
+		var source types.ValueSource
+		if fsys == nil {
+			source = FileSource(profilePath)
+		} else {
+			source = FSSource(fsys, profilePath)
+		}
+		sources = append(sources, profileSource{source.(*documentSource)})
+	}
+	return sources, nil
+}
+
+// loadProfileValues loads the overlays of path for the profiles active in
+// ctx. fsys is nil for the files of the OS.
+func loadProfileValues(ctx types.Context, path string, fsys fs.FS) error {
+	sources, err := profileSources(ctx, path, fsys)
+	if err != nil {
+		return err
+	}
+	return loadValues(ctx, sources)
+}
@@ -0,0 +1,298 @@
+package yadi
+
+import (
+	g "github.com/onsi/gomega"
+	"github.com/xbl4de/yadi/types"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+)
+
+type Mailer interface {
+	Send(to string) string
+}
+
+type SmtpMailer struct{}
+
+func (m *SmtpMailer) Send(to string) string {
+	return "smtp:" + to
+}
+
+type LogMailer struct {
+	Prefix string `yadi:"ignore"`
+}
+
+func (m *LogMailer) Send(to string) string {
+	return m.Prefix + to
+}
+
+func provideMailers() {
+	SetBeanProvider[Mailer](func(ctx types.Context) (Mailer, error) {
+		return &LogMailer{Prefix: "log:"}, nil
+	})
+	SetBeanProvider[Mailer](func(ctx types.Context) (Mailer, error) {
+		return &SmtpMailer{}, nil
+	}, WithProfile("prod"))
+	SetBeanProvider[Mailer](func(ctx types.Context) (Mailer, error) {
+		return &LogMailer{Prefix: "test:"}, nil
+	}, WithProfile("test"))
+}
+
+func TestProfile_ActiveProvider(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	provideMailers()
+	SetActiveProfiles("prod")
+	UseLazyContext()
+
+	mailer, err := GetBean[Mailer]()
+
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(mailer.Send("a")).Should(g.Equal("smtp:a"))
+}
+
+func TestProfile_FallsBackToProviderWithoutProfile(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	provideMailers()
+	SetActiveProfiles("dev")
+	UseLazyContext()
+
+	mailer, err := GetBean[Mailer]()
+
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(mailer.Send("a")).Should(g.Equal("log:a"))
+}
+
+func TestProfile_LaterProfileTakesPrecedence(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	provideMailers()
+	SetActiveProfiles("prod", "test")
+	UseLazyContext()
+
+	mailer, err := GetBean[Mailer]()
+
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(mailer.Send("a")).Should(g.Equal("test:a"))
+}
+
+func TestProfile_InactiveProviderIsNotUsed(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	SetBeanProvider[*LogMailer](func(ctx types.Context) (*LogMailer, error) {
+		return &LogMailer{Prefix: "prod:"}, nil
+	}, WithProfile("prod"))
+	SetBeanProvider[Plugin](func(ctx types.Context) (Plugin, error) {
+		return &NamedPlugin{Name: "prod"}, nil
+	}, WithBeanName("prodPlugin"), WithProfile("prod"))
+	UseLazyContext()
+
+	mailer, err := GetBean[*LogMailer]()
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(mailer.Prefix).Should(g.BeEmpty())
+
+	plugins, err := GetBeans[Plugin]()
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(plugins).Should(g.BeEmpty())
+}
+
+func TestProfile_FromEnv(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	t.Setenv(ActiveProfilesEnv, "dev, prod")
+	provideMailers()
+	UseLazyContext()
+
+	profiles, err := GetActiveProfiles()
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(profiles).Should(g.Equal([]string{"dev", "prod"}))
+	g.Expect(ValueOrigin(ActiveProfilesPath)).Should(g.Equal(types.ValueOrigin{
+		Layer: types.EnvLayer, Source: "env", Location: ActiveProfilesEnv,
+	}))
+
+	mailer, err := GetBean[Mailer]()
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(mailer.Send("a")).Should(g.Equal("smtp:a"))
+}
+
+func TestProfile_FromValuePath(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	t.Setenv(ActiveProfilesEnv, "dev")
+	provideMailers()
+	err := LoadValues(YAMLSource("config.yaml", strings.NewReader("yadi:\n  profiles:\n    active: [prod]\n")))
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	UseLazyContext()
+
+	g.Expect(GetActiveProfiles()).Should(g.Equal([]string{"dev"}))
+
+	SetActiveProfiles("test")
+	g.Expect(GetActiveProfiles()).Should(g.Equal([]string{"test"}))
+}
+
+func TestProfile_NoProfiles(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	UseLazyContext()
+
+	g.Expect(GetActiveProfiles()).Should(g.BeEmpty())
+}
+
+func TestProfile_PrimaryPerProfile(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	SetBeanProvider[*LogMailer](func(ctx types.Context) (*LogMailer, error) {
+		return &LogMailer{Prefix: "default:"}, nil
+	}, WithBeanName("default"), WithPrimary())
+	SetBeanProvider[*LogMailer](func(ctx types.Context) (*LogMailer, error) {
+		return &LogMailer{Prefix: "prod:"}, nil
+	}, WithBeanName("prod"), WithPrimary(), WithProfile("prod"))
+	SetActiveProfiles("prod")
+	UseLazyContext()
+
+	mailer, err := GetBean[*LogMailer]()
+
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(mailer.Prefix).Should(g.Equal("prod:"))
+}
+
+func TestProfile_OverlayFiles(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	writeFile(t, path, "db:\n  host: localhost\n  port: 5432\n")
+	writeFile(t, filepath.Join(dir, "config-prod.yaml"), "db:\n  host: prod.example.com\n")
+	writeFile(t, filepath.Join(dir, "config-eu.yaml"), "db:\n  host: eu.example.com\n")
+	SetActiveProfiles("prod", "eu", "missing")
+	err := LoadProfileValues(path)
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	err = LoadValues(FileSource(path))
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	UseLazyContext()
+
+	g.Expect(GetValue[string]("db.host")).Should(g.Equal("eu.example.com"))
+	g.Expect(GetValue[int]("db.port")).Should(g.Equal(5432))
+	origin, err := ValueOrigin("db.host")
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(origin.Layer).Should(g.Equal(types.ProfileLayer))
+	g.Expect(origin.Location).Should(g.Equal(filepath.Join(dir, "config-eu.yaml") + ":2:9"))
+}
+
+func TestProfile_OverlayFilesFS(t *testing.T) {
+	t.Parallel()
+	gt := g.NewWithT(t)
+	fsys := fstest.MapFS{
+		"config/app.json":      {Data: []byte(`{"server": {"port": 8080, "debug": true}}`)},
+		"config/app-prod.json": {Data: []byte(`{"server": {"debug": false}}`)},
+	}
+	c := NewContainer()
+	c.SetActiveProfiles("prod")
+
+	gt.Expect(c.LoadValues(FSSource(fsys, "config/app.json"))).Should(g.Succeed())
+	gt.Expect(c.LoadProfileValues(fsys, "config/app.json")).Should(g.Succeed())
+
+	gt.Expect(Value[int](c, "server.port")).Should(g.Equal(8080))
+	gt.Expect(Value[bool](c, "server.debug")).Should(g.BeFalse())
+}
+
+func TestProfile_InvalidOverlay(t *testing.T) {
+	t.Parallel()
+	gt := g.NewWithT(t)
+	fsys := fstest.MapFS{
+		"app-prod.json": {Data: []byte(`{"server": `)},
+	}
+	c := NewContainer()
+	c.SetActiveProfiles("prod")
+
+	err := c.LoadProfileValues(fsys, "app.json")
+
+	gt.Expect(err).Should(g.MatchError(types.ErrLoadValues))
+}
+
+func TestProfile_ChildContainer(t *testing.T) {
+	t.Parallel()
+	gt := g.NewWithT(t)
+	parent := NewContainer()
+	gt.Expect(Provide[Mailer](parent, func(ctx types.Context) (Mailer, error) {
+		return &LogMailer{Prefix: "log:"}, nil
+	})).Should(g.Succeed())
+	child := parent.Child()
+	gt.Expect(Provide[Mailer](child, func(ctx types.Context) (Mailer, error) {
+		return &SmtpMailer{}, nil
+	}, WithProfile("prod"))).Should(g.Succeed())
+
+	mailer, err := Get[Mailer](child)
+	gt.Expect(err).ShouldNot(g.HaveOccurred())
+	gt.Expect(mailer.Send("a")).Should(g.Equal("log:a"))
+
+	prodChild := parent.Child()
+	prodChild.SetActiveProfiles("prod")
+	gt.Expect(Provide[Mailer](prodChild, func(ctx types.Context) (Mailer, error) {
+		return &SmtpMailer{}, nil
+	}, WithProfile("prod"))).Should(g.Succeed())
+
+	mailer, err = Get[Mailer](prodChild)
+	gt.Expect(err).ShouldNot(g.HaveOccurred())
+	gt.Expect(mailer.Send("a")).Should(g.Equal("smtp:a"))
+}
+
+func TestProfileFilePath(t *testing.T) {
+	g.RegisterTestingT(t)
+
+	g.Expect(ProfileFilePath("config/app.yaml", "prod")).Should(g.Equal("config/app-prod.yaml"))
+	g.Expect(ProfileFilePath("app", "prod")).Should(g.Equal("app-prod"))
+}
+
+func writeFile(t *testing.T, path string, content string) {
+	err := os.WriteFile(path, []byte(content), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// countingProfilesLookup counts how often the active profiles are read.
+type countingProfilesLookup struct {
+	profiles string
+	reads    *atomic.Int32
+}
+
+func (l countingProfilesLookup) Name() string {
+	return "counting"
+}
+
+func (l countingProfilesLookup) Lookup(path string) (interface{}, bool) {
+	if path != ActiveProfilesPath {
+		return nil, false
+	}
+	l.reads.Add(1)
+	return l.profiles, true
+}
+
+func (l countingProfilesLookup) Load() (map[string]interface{}, error) {
+	return map[string]interface{}{ActiveProfilesPath: l.profiles}, nil
+}
+
+func TestProfile_ReadOnceUntilValuesChange(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	reads := &atomic.Int32{}
+	provideMailers()
+	err := LoadValues(countingProfilesLookup{profiles: "prod", reads: reads})
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	UseLazyContext()
+
+	for range 5 {
+		mailer, err := GetBean[Mailer]()
+		g.Expect(err).ShouldNot(g.HaveOccurred())
+		g.Expect(mailer.Send("a")).Should(g.Equal("smtp:a"))
+	}
+	g.Expect(reads.Load()).Should(g.BeNumerically("==", 1))
+
+	SetActiveProfiles("test")
+	g.Expect(GetActiveProfiles()).Should(g.Equal([]string{"test"}))
+}
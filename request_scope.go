@@ -0,0 +1,118 @@
+package yadi
+
+import (
+	"context"
+	"github.com/xbl4de/yadi/log"
+	"github.com/xbl4de/yadi/types"
+	"net/http"
+	"slices"
+	"sync"
+)
+
+// RequestScope keeps one instance of the bean per request scope opened with
+// BeginRequestScope or RequestScopeMiddleware. Beans are looked up in the
+// context.Context passed to GetBeanCtx and closed when the scope ends.
+// Singletons must not depend on request scoped beans: they would keep the
+// instance of the first request.
+var RequestScope types.Scope = requestScope{}
+
+type requestScopeKey struct{}
+
+type requestScope struct{}
+
+type requestBeans struct {
+	mu      sync.Mutex
+	beans   map[BeanKey]*beanBuild
+	created []*types.BeanContainer
+}
+
+func WithRequestScope() func(provider *types.BeanProvider) {
+	return WithScope(RequestScope)
+}
+
+// BeginRequestScope opens a request scope. The returned function closes the
+// beans built in the scope, in reverse creation order.
+func BeginRequestScope(ctx context.Context) (context.Context, func() error) {
+	beans := &requestBeans{
+		beans: make(map[BeanKey]*beanBuild),
+	}
+	scopeCtx := context.WithValue(ctx, requestScopeKey{}, beans)
+	return scopeCtx, func() error {
+		return beans.close(context.WithoutCancel(scopeCtx))
+	}
+}
+
+func RequestScopeMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, endScope := BeginRequestScope(r.Context())
+		defer func() {
+			err := endScope()
+			if err != nil {
+				log.Log("failed to end request scope: %+v", err)
+			}
+		}()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func (requestScope) Get(request types.ScopeRequest, factory func() (*types.BeanContainer, error)) (types.Bean, error) {
+	beans, ok := request.Context.Value(requestScopeKey{}).(*requestBeans)
+	if !ok {
+		return nil, types.ErrNoRequestScope
+	}
+	r, _ := request.Context.Value(resolutionKey{}).(*resolution)
+	beanContainer, err := beans.get(r, NewBeanKey(request.Type, request.Name), factory)
+	if err != nil {
+		return nil, err
+	}
+	return beanContainer.Bean, nil
+}
+
+// get builds the bean once per request. r is the resolution asking for the
+// bean, if any, so goroutines of the request waiting for each other's beans
+// fail instead of blocking.
+func (b *requestBeans) get(
+	r *resolution,
+	key BeanKey,
+	factory func() (*types.BeanContainer, error),
+) (*types.BeanContainer, error) {
+	b.mu.Lock()
+	if build, ok := b.beans[key]; ok {
+		b.mu.Unlock()
+		if r != nil {
+			return r.waitFor(build)
+		}
+		<-build.done
+		return build.container, build.err
+	}
+	build := &beanBuild{
+		done: make(chan struct{}),
+	}
+	if r != nil {
+		build.owner = r.chain
+	}
+	b.beans[key] = build
+	b.mu.Unlock()
+
+	build.container, build.err = factory()
+
+	b.mu.Lock()
+	if build.err != nil {
+		delete(b.beans, key)
+	} else {
+		b.created = append(b.created, build.container)
+	}
+	b.mu.Unlock()
+	close(build.done)
+
+	return build.container, build.err
+}
+
+func (b *requestBeans) close(ctx context.Context) error {
+	b.mu.Lock()
+	beans := slices.Clone(b.created)
+	b.created = nil
+	b.mu.Unlock()
+	slices.Reverse(beans)
+	return closeBeans(ctx, beans)
+}
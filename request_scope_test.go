@@ -0,0 +1,179 @@
+package yadi
+
+import (
+	"context"
+	"fmt"
+	g "github.com/onsi/gomega"
+	"github.com/xbl4de/yadi/types"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+)
+
+type CurrentUser struct {
+	Name   string
+	Closed bool
+}
+
+func (u *CurrentUser) Close() error {
+	u.Closed = true
+	return nil
+}
+
+type GreetingService struct {
+	User *CurrentUser
+}
+
+func newRequestScopeContainer(users *atomic.Int32) *Container {
+	c := NewContainer()
+	_ = Provide(c, func(ctx types.Context) (*CurrentUser, error) {
+		return &CurrentUser{Name: fmt.Sprintf("user-%d", users.Add(1))}, nil
+	}, WithRequestScope())
+	_ = ProvideFunc[*GreetingService](c, func(user *CurrentUser) *GreetingService {
+		return &GreetingService{User: user}
+	}, WithProviderOptions(WithPrototypeScope()))
+	return c
+}
+
+func TestRequestScope_SameInstanceWithinScope(t *testing.T) {
+	t.Parallel()
+	gt := g.NewWithT(t)
+	users := &atomic.Int32{}
+	c := newRequestScopeContainer(users)
+
+	ctx, endScope := BeginRequestScope(context.Background())
+	first, err := GetCtx[*CurrentUser](c, ctx)
+	gt.Expect(err).ShouldNot(g.HaveOccurred())
+	service, err := GetCtx[*GreetingService](c, ctx)
+	gt.Expect(err).ShouldNot(g.HaveOccurred())
+
+	gt.Expect(service.User).Should(g.BeIdenticalTo(first))
+	gt.Expect(endScope()).Should(g.Succeed())
+	gt.Expect(first.Closed).Should(g.BeTrue())
+
+	ctx, endScope = BeginRequestScope(context.Background())
+	defer func() {
+		_ = endScope()
+	}()
+	second, err := GetCtx[*CurrentUser](c, ctx)
+	gt.Expect(err).ShouldNot(g.HaveOccurred())
+	gt.Expect(second).ShouldNot(g.BeIdenticalTo(first))
+	gt.Expect(users.Load()).Should(g.Equal(int32(2)))
+}
+
+func TestRequestScope_NoActiveScope(t *testing.T) {
+	t.Parallel()
+	gt := g.NewWithT(t)
+	c := newRequestScopeContainer(&atomic.Int32{})
+
+	_, err := Get[*CurrentUser](c)
+
+	gt.Expect(err).Should(g.MatchError(types.ErrNoRequestScope))
+}
+
+func TestRequestScopeMiddleware(t *testing.T) {
+	t.Parallel()
+	gt := g.NewWithT(t)
+	users := &atomic.Int32{}
+	c := newRequestScopeContainer(users)
+	var served []*CurrentUser
+
+	handler := RequestScopeMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		service, err := GetCtx[*GreetingService](c, r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		user, err := GetCtx[*CurrentUser](c, r.Context())
+		if err != nil || user != service.User {
+			http.Error(w, "unexpected user", http.StatusInternalServerError)
+			return
+		}
+		served = append(served, user)
+		_, _ = fmt.Fprintf(w, "hello %s", service.User.Name)
+	}))
+
+	for i := 1; i <= 2; i++ {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		gt.Expect(recorder.Code).Should(g.Equal(http.StatusOK))
+		gt.Expect(recorder.Body.String()).Should(g.Equal(fmt.Sprintf("hello user-%d", i)))
+	}
+	gt.Expect(served).Should(g.HaveLen(2))
+	gt.Expect(served[0].Closed).Should(g.BeTrue())
+	gt.Expect(served[1].Closed).Should(g.BeTrue())
+}
+
+func TestGetBeanCtx_Global(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	UseLazyContext()
+	SetBeanProvider[*CurrentUser](func(ctx types.Context) (*CurrentUser, error) {
+		return &CurrentUser{Name: "global"}, nil
+	}, WithRequestScope())
+
+	ctx, endScope := BeginRequestScope(context.Background())
+	user, err := GetBeanCtx[*CurrentUser](ctx)
+
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(user.Name).Should(g.Equal("global"))
+	g.Expect(endScope()).Should(g.Succeed())
+	g.Expect(user.Closed).Should(g.BeTrue())
+}
+
+type RequestA struct {
+	B *RequestB
+}
+
+type RequestB struct {
+	A *RequestA
+}
+
+func TestRequestScope_ConcurrentCycle(t *testing.T) {
+	t.Parallel()
+	gt := g.NewWithT(t)
+	c := NewContainer()
+	startedA := make(chan struct{})
+	startedB := make(chan struct{})
+	_ = Provide(c, func(ctx types.Context) (*RequestA, error) {
+		close(startedA)
+		<-startedB
+		b, err := ctx.Get(reflect.TypeFor[*RequestB]())
+		if err != nil {
+			return nil, err
+		}
+		return &RequestA{B: b.(*RequestB)}, nil
+	}, WithRequestScope())
+	_ = Provide(c, func(ctx types.Context) (*RequestB, error) {
+		close(startedB)
+		<-startedA
+		a, err := ctx.Get(reflect.TypeFor[*RequestA]())
+		if err != nil {
+			return nil, err
+		}
+		return &RequestB{A: a.(*RequestA)}, nil
+	}, WithRequestScope())
+
+	ctx, endScope := BeginRequestScope(context.Background())
+	defer func() {
+		_ = endScope()
+	}()
+	errs := make(chan error, 2)
+	go func() {
+		_, err := GetCtx[*RequestA](c, ctx)
+		errs <- err
+	}()
+	go func() {
+		_, err := GetCtx[*RequestB](c, ctx)
+		errs <- err
+	}()
+
+	for i := 0; i < 2; i++ {
+		var err error
+		gt.Eventually(errs).Should(g.Receive(&err))
+		gt.Expect(err).Should(g.MatchError(types.ErrCycleDependencies))
+	}
+}
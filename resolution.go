@@ -0,0 +1,221 @@
+package yadi
+
+import (
+	"context"
+	"fmt"
+	"github.com/xbl4de/yadi/types"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// waitGraphMu guards resolutionChain.waitingFor of every chain, so wait cycles
+// between goroutines can be detected atomically.
+var waitGraphMu sync.Mutex
+
+// resolutionChain identifies one top-level lookup and every nested lookup it
+// triggers. A chain blocks at most on one in-flight build at a time.
+type resolutionChain struct {
+	waitingFor *beanBuild
+}
+
+// resolutionKey holds the resolution asking a types.Scope for a bean in the
+// context.Context of the types.ScopeRequest.
+type resolutionKey struct{}
+
+// resolution is the view of a LazyContext handed to bean builders. It carries
+// the keys being resolved by the current chain, so nested lookups detect
+// cycles without sharing state between goroutines.
+type resolution struct {
+	ctx    *LazyContext
+	goCtx  context.Context
+	chain  *resolutionChain
+	parent *resolution
+	key    BeanKey
+	depth  int
+}
+
+func newResolution(ctx *LazyContext) *resolution {
+	return newResolutionWithContext(ctx, context.Background())
+}
+
+func newResolutionWithContext(ctx *LazyContext, goCtx context.Context) *resolution {
+	return &resolution{
+		ctx:   ctx,
+		goCtx: goCtx,
+		chain: &resolutionChain{},
+	}
+}
+
+func (r *resolution) push(key BeanKey) (*resolution, error) {
+	if r.contains(key) {
+		return nil, fmt.Errorf("%w: cannot inject to\n%s", types.ErrCycleDependencies, r.dumpDiStack(key))
+	}
+	return &resolution{
+		ctx:    r.ctx,
+		goCtx:  r.goCtx,
+		chain:  r.chain,
+		parent: r,
+		key:    key,
+		depth:  r.depth + 1,
+	}, nil
+}
+
+// in returns the same position of the chain resolved by another context.
+func (r *resolution) in(ctx *LazyContext) *resolution {
+	return &resolution{
+		ctx:    ctx,
+		goCtx:  r.goCtx,
+		chain:  r.chain,
+		parent: r.parent,
+		key:    r.key,
+		depth:  r.depth,
+	}
+}
+
+func (r *resolution) contains(key BeanKey) bool {
+	for el := r; el.depth > 0; el = el.parent {
+		if el.key == key {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *resolution) stack() []BeanKey {
+	keys := make([]BeanKey, r.depth)
+	for el := r; el.depth > 0; el = el.parent {
+		keys[el.depth-1] = el.key
+	}
+	return keys
+}
+
+func (r *resolution) dumpDiStack(toAppend BeanKey) string {
+	builder := strings.Builder{}
+	keys := r.stack()
+	if len(keys) > 0 {
+		firstEl := keys[0]
+		builder.WriteString(fmt.Sprintf("%s[%s]\n", firstEl.Name, firstEl.Type.String()))
+		for _, el := range keys[1:] {
+			builder.WriteString(fmt.Sprintf("↳  %s[%s]\n", el.Name, el.Type.String()))
+		}
+	}
+	builder.WriteString(fmt.Sprintf("→ %s[%s]\n", toAppend.Name, toAppend.Type.String()))
+	return builder.String()
+}
+
+// waitFor blocks until the build started by another chain finishes. It fails
+// instead of blocking when that chain, directly or transitively, waits for
+// a build owned by this chain.
+func (r *resolution) waitFor(build *beanBuild) (*types.BeanContainer, error) {
+	waitGraphMu.Lock()
+	for owner := build.owner; owner != nil; {
+		if owner == r.chain {
+			waitGraphMu.Unlock()
+			return nil, fmt.Errorf("%w: bean is being built by a resolution waiting for\n%s",
+				types.ErrCycleDependencies, r.parent.dumpDiStack(r.key))
+		}
+		if owner.waitingFor == nil {
+			break
+		}
+		owner = owner.waitingFor.owner
+	}
+	r.chain.waitingFor = build
+	waitGraphMu.Unlock()
+
+	<-build.done
+
+	waitGraphMu.Lock()
+	r.chain.waitingFor = nil
+	waitGraphMu.Unlock()
+
+	return build.container, build.err
+}
+
+func (r *resolution) NewChild() types.Context {
+	return r.ctx.NewChild()
+}
+
+func (r *resolution) Init() {
+	r.ctx.Init()
+}
+
+func (r *resolution) Close(goCtx context.Context) error {
+	return r.ctx.Close(goCtx)
+}
+
+func (r *resolution) Register(provider *types.BeanProvider) error {
+	return r.ctx.Register(provider)
+}
+
+// Get returns the primary bean of typ if there is one, otherwise the unnamed
+// bean.
+func (r *resolution) Get(typ reflect.Type) (types.Bean, error) {
+	if key, ok := r.ctx.primaryKey(typ); ok {
+		return r.ctx.get(r, key, true)
+	}
+	return r.ctx.get(r, NewBeanKey(typ, ""), true)
+}
+
+func (r *resolution) GetNamed(typ reflect.Type, beanName string) (types.Bean, error) {
+	return r.ctx.get(r, NewBeanKey(typ, beanName), false)
+}
+
+func (r *resolution) GetAll(typ reflect.Type) ([]*types.BeanContainer, error) {
+	return r.ctx.getAll(r, typ)
+}
+
+func (r *resolution) matchingProviders(typ reflect.Type) []*types.BeanProvider {
+	return r.ctx.matchingProviders(typ)
+}
+
+func (r *resolution) GetWithContext(goCtx context.Context, typ reflect.Type) (types.Bean, error) {
+	return r.withContext(goCtx).Get(typ)
+}
+
+func (r *resolution) GetNamedWithContext(goCtx context.Context, typ reflect.Type, beanName string) (types.Bean, error) {
+	return r.withContext(goCtx).GetNamed(typ, beanName)
+}
+
+func (r *resolution) withContext(goCtx context.Context) *resolution {
+	return &resolution{
+		ctx:    r.ctx,
+		goCtx:  goCtx,
+		chain:  r.chain,
+		parent: r.parent,
+		key:    r.key,
+		depth:  r.depth,
+	}
+}
+
+func (r *resolution) GetGenericValue(path string) (interface{}, error) {
+	return r.ctx.GetGenericValue(path)
+}
+
+func (r *resolution) SetGenericValue(path string, value interface{}) {
+	r.ctx.SetGenericValue(path, value)
+}
+
+func (r *resolution) SetLayeredValue(path string, value interface{}, origin types.ValueOrigin) {
+	r.ctx.SetLayeredValue(path, value, origin)
+}
+
+func (r *resolution) ValueOrigin(path string) (types.ValueOrigin, error) {
+	return r.ctx.ValueOrigin(path)
+}
+
+func (r *resolution) DeleteValue(path string) {
+	r.ctx.DeleteValue(path)
+}
+
+func (r *resolution) AddValueLookup(lookup types.ValueLookup) {
+	r.ctx.AddValueLookup(lookup)
+}
+
+func (r *resolution) GetValuesByPrefix(prefix string) map[string]interface{} {
+	return r.ctx.GetValuesByPrefix(prefix)
+}
+
+func (r *resolution) ValueKeys() []string {
+	return r.ctx.ValueKeys()
+}
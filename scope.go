@@ -0,0 +1,31 @@
+package yadi
+
+import (
+	"github.com/xbl4de/yadi/types"
+)
+
+// PrototypeScope builds a new bean on every lookup or injection. The context
+// does not keep prototype beans, so closing them is up to the user.
+var PrototypeScope types.Scope = prototypeScope{}
+
+type prototypeScope struct{}
+
+func (prototypeScope) Get(_ types.ScopeRequest, factory func() (*types.BeanContainer, error)) (types.Bean, error) {
+	beanContainer, err := factory()
+	if err != nil {
+		return nil, err
+	}
+	return beanContainer.Bean, nil
+}
+
+// WithScope sets the scope of the bean. Beans without a scope are singletons
+// held by the context.
+func WithScope(scope types.Scope) func(provider *types.BeanProvider) {
+	return func(provider *types.BeanProvider) {
+		provider.Scope = scope
+	}
+}
+
+func WithPrototypeScope() func(provider *types.BeanProvider) {
+	return WithScope(PrototypeScope)
+}
@@ -0,0 +1,120 @@
+package yadi
+
+import (
+	"context"
+	g "github.com/onsi/gomega"
+	"github.com/xbl4de/yadi/types"
+	"sync"
+	"testing"
+)
+
+type PrototypeHolder struct {
+	ServiceE *ServiceE
+}
+
+type PrototypeCycle struct {
+	Cycle *PrototypeCycle
+}
+
+type countingScope struct {
+	mu       sync.Mutex
+	requests []types.ScopeRequest
+	bean     types.Bean
+}
+
+func (s *countingScope) Get(request types.ScopeRequest, factory func() (*types.BeanContainer, error)) (types.Bean, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, request)
+	if s.bean != nil {
+		return s.bean, nil
+	}
+	beanContainer, err := factory()
+	if err != nil {
+		return nil, err
+	}
+	s.bean = beanContainer.Bean
+	return s.bean, nil
+}
+
+func TestPrototypeScope_NewInstanceOnEveryLookup(t *testing.T) {
+	t.Parallel()
+	gt := g.NewWithT(t)
+	c := NewContainer()
+	err := Provide(c, func(ctx types.Context) (*ServiceE, error) {
+		return NewServiceE("prototype"), nil
+	}, WithPrototypeScope())
+	gt.Expect(err).ShouldNot(g.HaveOccurred())
+
+	first := Require[*ServiceE](c)
+	second := Require[*ServiceE](c)
+
+	gt.Expect(first.Description).Should(g.Equal("prototype"))
+	gt.Expect(first).ShouldNot(g.BeIdenticalTo(second))
+}
+
+func TestPrototypeScope_NewInstanceOnEveryInjection(t *testing.T) {
+	t.Parallel()
+	gt := g.NewWithT(t)
+	c := NewContainer()
+	err := ProvideFunc[*ServiceE](c, NewServiceE,
+		WithDefaultValueAt(0, "prototype"),
+		WithProviderOptions(WithPrototypeScope()))
+	gt.Expect(err).ShouldNot(g.HaveOccurred())
+
+	first := PrototypeHolder{}
+	second := PrototypeHolder{}
+	gt.Expect(c.Inject(&first)).Should(g.Succeed())
+	gt.Expect(c.Inject(&second)).Should(g.Succeed())
+
+	gt.Expect(first.ServiceE).ShouldNot(g.BeIdenticalTo(second.ServiceE))
+}
+
+func TestPrototypeScope_CycleDetected(t *testing.T) {
+	t.Parallel()
+	gt := g.NewWithT(t)
+	c := NewContainer()
+	err := Provide(c, func(ctx types.Context) (*PrototypeCycle, error) {
+		cycle, err := Get[*PrototypeCycle](ctx)
+		return &PrototypeCycle{Cycle: cycle}, err
+	}, WithPrototypeScope())
+	gt.Expect(err).ShouldNot(g.HaveOccurred())
+
+	_, err = Get[*PrototypeCycle](c)
+
+	gt.Expect(err).Should(g.MatchError(types.ErrCycleDependencies))
+}
+
+func TestPrototypeScope_NotClosedByContext(t *testing.T) {
+	t.Parallel()
+	gt := g.NewWithT(t)
+	c := NewContainer()
+	err := ProvideFunc[*ServiceClose](c, NewServiceClose, WithProviderOptions(WithPrototypeScope()))
+	gt.Expect(err).ShouldNot(g.HaveOccurred())
+
+	bean := Require[*ServiceClose](c)
+	err = c.Close(context.Background())
+
+	gt.Expect(err).ShouldNot(g.HaveOccurred())
+	gt.Expect(bean.Closed).Should(g.BeFalse())
+}
+
+func TestCustomScope(t *testing.T) {
+	t.Parallel()
+	gt := g.NewWithT(t)
+	c := NewContainer()
+	scope := &countingScope{}
+	err := ProvideFunc[*ServiceE](c, NewServiceE,
+		WithDefaultValueAt(0, "scoped"),
+		WithFuncProviderBeanName("scoped"),
+		WithProviderOptions(WithScope(scope)))
+	gt.Expect(err).ShouldNot(g.HaveOccurred())
+
+	first := RequireNamed[*ServiceE](c, "scoped")
+	second := RequireNamed[*ServiceE](c, "scoped")
+
+	gt.Expect(first).Should(g.BeIdenticalTo(second))
+	gt.Expect(scope.requests).Should(g.HaveLen(2))
+	gt.Expect(scope.requests[0].Name).Should(g.Equal("scoped"))
+	gt.Expect(scope.requests[0].Type.String()).Should(g.Equal("*yadi.ServiceE"))
+}
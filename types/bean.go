@@ -11,6 +11,7 @@ type BeanContainer struct {
 	Name          string
 	Type          reflect.Type
 	HoldByContext bool
+	DestroyFuncs  []DestroyFunc
 }
 
 func NewBeanContainer(
@@ -54,10 +55,18 @@ func NewBeanContainerHoldByUser(
 }
 
 type BeanProvider struct {
-	Builder         func(ctx Context) (Bean, error)
-	BeanType        reflect.Type
-	BeanName        string
-	Options         []func(provider *BeanProvider)
-	UseExistingBean reflect.Type
-	HoldByContext   bool
+	Builder          func(ctx Context) (Bean, error)
+	BeanType         reflect.Type
+	BeanName         string
+	Options          []func(provider *BeanProvider)
+	UseExistingBean  reflect.Type
+	ExistingBeanName string
+	HoldByContext    bool
+	Lazy             bool
+	Order            int
+	Primary          bool
+	Profile          string
+	Scope            Scope
+	InitFuncs        []InitFunc
+	DestroyFuncs     []DestroyFunc
 }
@@ -1,15 +1,26 @@
 package types
 
 import (
+	"context"
 	"reflect"
 )
 
 type Context interface {
 	Init()
-	Close() error
+	Close(ctx context.Context) error
 	Register(ctx *BeanProvider) error
 	Get(typ reflect.Type) (Bean, error)
 	GetNamed(typ reflect.Type, beanName string) (Bean, error)
+	GetAll(typ reflect.Type) ([]*BeanContainer, error)
+	GetWithContext(ctx context.Context, typ reflect.Type) (Bean, error)
+	GetNamedWithContext(ctx context.Context, typ reflect.Type, beanName string) (Bean, error)
 	GetGenericValue(path string) (interface{}, error)
 	SetGenericValue(path string, value interface{})
+	SetLayeredValue(path string, value interface{}, origin ValueOrigin)
+	ValueOrigin(path string) (ValueOrigin, error)
+	DeleteValue(path string)
+	AddValueLookup(lookup ValueLookup)
+	GetValuesByPrefix(prefix string) map[string]interface{}
+	ValueKeys() []string
+	NewChild() Context
 }
@@ -10,6 +10,14 @@ var ErrParseTag = errors.New("parse tag error")
 var ErrNilContext = errors.New("nil context")
 var ErrContextAlreadyExists = errors.New("context already exists")
 var ErrCycleDependencies = errors.New("detected cycle dependency")
+var ErrEagerInit = errors.New("failed to init eager context")
+var ErrNoRequestScope = errors.New("no request scope in context")
+var ErrIncompatibleBeanType = errors.New("incompatible bean type")
+var ErrAmbiguousBean = errors.New("ambiguous bean")
+var ErrMultiplePrimaryBeans = errors.New("multiple primary beans")
+var ErrValueConversion = errors.New("value conversion failed")
+var ErrLoadValues = errors.New("failed to load values")
+var ErrUnresolvedPlaceholder = errors.New("unresolved placeholder")
 
 func ErrNoInjectableProvided(err error) bool {
 	return errors.Is(err, ErrNoBeanProvider) || errors.Is(err, ErrNoValueFound)
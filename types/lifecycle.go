@@ -0,0 +1,22 @@
+package types
+
+import "context"
+
+// AfterInjector is called once the bean is built and its fields are injected.
+type AfterInjector interface {
+	AfterInject() error
+}
+
+// Validator is called after AfterInjector to check that the bean is usable.
+type Validator interface {
+	Validate() error
+}
+
+// BeforeCloser is called when the context closes the bean, before io.Closer.
+type BeforeCloser interface {
+	BeforeClose(ctx context.Context) error
+}
+
+type InitFunc func(bean Bean) error
+
+type DestroyFunc func(ctx context.Context, bean Bean) error
@@ -0,0 +1,44 @@
+package types
+
+import "reflect"
+
+// Optional holds a dependency that may be missing. Fields and provider
+// function parameters of this type are left empty instead of failing the
+// build when the dependency cannot be resolved.
+type Optional[T any] struct {
+	value   T
+	present bool
+}
+
+// OptionalValue is implemented by *Optional to let the context fill it.
+type OptionalValue interface {
+	ValueType() reflect.Type
+	SetValue(value interface{})
+}
+
+func NewOptional[T any](value T) Optional[T] {
+	return Optional[T]{
+		value:   value,
+		present: true,
+	}
+}
+
+func (o Optional[T]) Get() (T, bool) {
+	return o.value, o.present
+}
+
+func (o Optional[T]) OrElse(defaultValue T) T {
+	if o.present {
+		return o.value
+	}
+	return defaultValue
+}
+
+func (o Optional[T]) ValueType() reflect.Type {
+	return reflect.TypeFor[T]()
+}
+
+func (o *Optional[T]) SetValue(value interface{}) {
+	o.value = value.(T)
+	o.present = true
+}
@@ -0,0 +1,21 @@
+package types
+
+import (
+	"context"
+	"reflect"
+)
+
+// ScopeRequest describes the requested bean. Context is the context.Context
+// the lookup was made with, or context.Background() for plain lookups.
+type ScopeRequest struct {
+	Context context.Context
+	Type    reflect.Type
+	Name    string
+}
+
+// Scope decides when a bean is built and how long the instance is reused.
+// The factory builds a new instance with all its dependencies and lifecycle
+// hooks applied; scopes caching instances are responsible for destroying them.
+type Scope interface {
+	Get(request ScopeRequest, factory func() (*BeanContainer, error)) (Bean, error)
+}
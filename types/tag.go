@@ -7,17 +7,24 @@ import (
 )
 
 type Tag struct {
-	Ignore    bool
-	BeanName  string
-	ValuePath string
+	Ignore       bool
+	Optional     bool
+	BeanName     string
+	ValuePath    string
+	EnvName      string
+	DefaultValue string
+	HasDefault   bool
 }
 
 const TagName = "yadi"
 
 const (
 	IgnoreValue  = "ignore"
+	OptionalTag  = "optional"
 	BeanNameTag  = "beanName"
 	ValuePathTag = "path"
+	DefaultTag   = "default"
+	EnvTag       = "env"
 )
 
 type tagModifier func(*Tag, string) error
@@ -26,8 +33,19 @@ var emptyTag = Tag{}
 
 var modifiers = map[string]tagModifier{
 	IgnoreValue:  applyIgnoreTag,
+	OptionalTag:  applyOptionalTag,
 	BeanNameTag:  applyBeanNameTag,
 	ValuePathTag: applyPathTag,
+	DefaultTag:   applyDefaultTag,
+	EnvTag:       applyEnvTag,
+}
+
+// tagPart is a single key[=value] entry of the tag with quotes and escapes
+// already resolved.
+type tagPart struct {
+	key      string
+	value    string
+	hasValue bool
 }
 
 func applyIgnoreTag(tag *Tag, _ string) error {
@@ -35,6 +53,11 @@ func applyIgnoreTag(tag *Tag, _ string) error {
 	return nil
 }
 
+func applyOptionalTag(tag *Tag, _ string) error {
+	tag.Optional = true
+	return nil
+}
+
 func applyBeanNameTag(tag *Tag, value string) error {
 	if value == "" {
 		return errors.Errorf("Expected non-empty beanName, but got %s", value)
@@ -51,12 +74,28 @@ func applyPathTag(tag *Tag, value string) error {
 	return nil
 }
 
+func applyDefaultTag(tag *Tag, value string) error {
+	tag.DefaultValue = value
+	tag.HasDefault = true
+	return nil
+}
+
+func applyEnvTag(tag *Tag, value string) error {
+	if value == "" {
+		return errors.Errorf(`"%s" is empty`, EnvTag)
+	}
+	tag.EnvName = value
+	return nil
+}
+
 func ParseTag(tag string) (*Tag, error) {
 	if strings.TrimSpace(tag) == "" {
 		return &emptyTag, nil
 	}
-	var err error
-	parts := strings.Split(tag, ";")
+	parts, err := splitTag(tag)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrParseTag, err)
+	}
 	newTag := &Tag{}
 	for _, part := range parts {
 		err = applyPart(part, newTag)
@@ -64,22 +103,86 @@ func ParseTag(tag string) (*Tag, error) {
 			return nil, fmt.Errorf("%w: %s", ErrParseTag, err)
 		}
 	}
+	err = validateTag(newTag)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrParseTag, err)
+	}
 	return newTag, nil
 }
 
-func applyPart(part string, tag *Tag) error {
-	partArray := strings.Split(part, "=")
-	if len(partArray) > 2 {
-		return fmt.Errorf("expected key=value, got %s", part)
+func validateTag(tag *Tag) error {
+	if tag.BeanName != "" && tag.ValuePath != "" {
+		return errors.Errorf("'%s' cannot be combined with '%s'", BeanNameTag, ValuePathTag)
 	}
-	key := partArray[0]
-	value := ""
-	if len(partArray) == 2 {
-		value = partArray[1]
+	if tag.BeanName != "" && tag.Ignore {
+		return errors.Errorf("'%s' cannot be combined with '%s'", BeanNameTag, IgnoreValue)
 	}
-	modifier, ok := modifiers[key]
+	if tag.BeanName != "" && tag.EnvName != "" {
+		return errors.Errorf("'%s' cannot be combined with '%s'", BeanNameTag, EnvTag)
+	}
+	if tag.HasDefault && tag.ValuePath == "" && tag.EnvName == "" {
+		return errors.Errorf("'%s' requires '%s' or '%s'", DefaultTag, ValuePathTag, EnvTag)
+	}
+	return nil
+}
+
+// splitTag splits the tag into ';'-separated parts. Values may contain ';'
+// and '=' when wrapped in single quotes or escaped with a backslash, which is
+// written as \\ in a struct tag since tags are quoted Go strings.
+func splitTag(tag string) ([]tagPart, error) {
+	var parts []tagPart
+	current := tagPart{}
+	builder := strings.Builder{}
+	inQuotes := false
+	escaped := false
+	for _, r := range tag {
+		switch {
+		case escaped:
+			builder.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '\'':
+			inQuotes = !inQuotes
+		case inQuotes:
+			builder.WriteRune(r)
+		case r == '=':
+			if current.hasValue {
+				return nil, errors.Errorf("expected key=value, got %s", tag)
+			}
+			current.key = builder.String()
+			current.hasValue = true
+			builder.Reset()
+		case r == ';':
+			parts = append(parts, current.finish(builder.String()))
+			current = tagPart{}
+			builder.Reset()
+		default:
+			builder.WriteRune(r)
+		}
+	}
+	if escaped {
+		return nil, errors.Errorf("unfinished escape sequence in %s", tag)
+	}
+	if inQuotes {
+		return nil, errors.Errorf("unterminated quote in %s", tag)
+	}
+	return append(parts, current.finish(builder.String())), nil
+}
+
+func (p tagPart) finish(text string) tagPart {
+	if p.hasValue {
+		p.value = text
+	} else {
+		p.key = text
+	}
+	return p
+}
+
+func applyPart(part tagPart, tag *Tag) error {
+	modifier, ok := modifiers[part.key]
 	if !ok {
-		return errors.Errorf("unknown tag '%s'", key)
+		return errors.Errorf("unknown tag '%s'", part.key)
 	}
-	return modifier(tag, value)
+	return modifier(tag, part.value)
 }
@@ -20,14 +20,27 @@ func TestParseTag_EmptyTag(t *testing.T) {
 func TestParseTag_FullTag(t *testing.T) {
 	g.RegisterTestingT(t)
 
-	tag, err := ParseTag("ignore;path=abc;beanName=def")
+	tag, err := ParseTag("ignore;path=abc")
 	g.Expect(err).ShouldNot(g.HaveOccurred())
 	g.Expect(tag).ShouldNot(g.BeNil())
 	g.Expect(tag.ValuePath).Should(g.Equal("abc"))
-	g.Expect(tag.BeanName).Should(g.Equal("def"))
 	g.Expect(tag.Ignore).Should(g.BeTrue())
 }
 
+func TestParseTag_BeanNameWithPath(t *testing.T) {
+	g.RegisterTestingT(t)
+	_, err := ParseTag("path=abc;beanName=def")
+
+	g.Expect(err).Should(g.MatchError(ErrParseTag))
+}
+
+func TestParseTag_BeanNameWithIgnore(t *testing.T) {
+	g.RegisterTestingT(t)
+	_, err := ParseTag("ignore;beanName=def")
+
+	g.Expect(err).Should(g.MatchError(ErrParseTag))
+}
+
 func TestParseTag_IgnoreTag(t *testing.T) {
 	g.RegisterTestingT(t)
 
@@ -38,6 +51,16 @@ func TestParseTag_IgnoreTag(t *testing.T) {
 	g.Expect(tag.BeanName).Should(g.BeEmpty())
 }
 
+func TestParseTag_OptionalTag(t *testing.T) {
+	g.RegisterTestingT(t)
+
+	tag, err := ParseTag("optional;path=abc")
+
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(tag.Optional).Should(g.BeTrue())
+	g.Expect(tag.ValuePath).Should(g.Equal("abc"))
+}
+
 func TestParseTag_BeanName(t *testing.T) {
 	g.RegisterTestingT(t)
 	tag, err := ParseTag("beanName=beanA")
@@ -87,3 +110,87 @@ func TestParseTag_ExtraValues(t *testing.T) {
 
 	g.Expect(err).Should(g.MatchError(ErrParseTag))
 }
+
+func TestParseTag_DefaultValue(t *testing.T) {
+	g.RegisterTestingT(t)
+
+	tag, err := ParseTag("path=server.port;default=8080")
+
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(tag.ValuePath).Should(g.Equal("server.port"))
+	g.Expect(tag.DefaultValue).Should(g.Equal("8080"))
+	g.Expect(tag.HasDefault).Should(g.BeTrue())
+}
+
+func TestParseTag_EmptyDefaultValue(t *testing.T) {
+	g.RegisterTestingT(t)
+
+	tag, err := ParseTag("path=server.host;default=")
+
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(tag.DefaultValue).Should(g.BeEmpty())
+	g.Expect(tag.HasDefault).Should(g.BeTrue())
+}
+
+func TestParseTag_QuotedDefaultValue(t *testing.T) {
+	g.RegisterTestingT(t)
+
+	tag, err := ParseTag("path=db.dsn;default='user=admin;password=secret'")
+
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(tag.DefaultValue).Should(g.Equal("user=admin;password=secret"))
+}
+
+func TestParseTag_EscapedDefaultValue(t *testing.T) {
+	g.RegisterTestingT(t)
+
+	tag, err := ParseTag(`path=greeting;default=it\'s a\;b\=c\\`)
+
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(tag.DefaultValue).Should(g.Equal(`it's a;b=c\`))
+}
+
+func TestParseTag_UnterminatedQuote(t *testing.T) {
+	g.RegisterTestingT(t)
+	_, err := ParseTag("path=abc;default='abc")
+
+	g.Expect(err).Should(g.MatchError(ErrParseTag))
+}
+
+func TestParseTag_UnfinishedEscape(t *testing.T) {
+	g.RegisterTestingT(t)
+	_, err := ParseTag(`path=abc;default=abc\`)
+
+	g.Expect(err).Should(g.MatchError(ErrParseTag))
+}
+
+func TestParseTag_DefaultWithoutPath(t *testing.T) {
+	g.RegisterTestingT(t)
+	_, err := ParseTag("default=abc")
+
+	g.Expect(err).Should(g.MatchError(ErrParseTag))
+}
+
+func TestParseTag_Env(t *testing.T) {
+	g.RegisterTestingT(t)
+
+	tag, err := ParseTag("env=DATABASE_URL;default='postgres://localhost'")
+
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(tag.EnvName).Should(g.Equal("DATABASE_URL"))
+	g.Expect(tag.DefaultValue).Should(g.Equal("postgres://localhost"))
+}
+
+func TestParseTag_EmptyEnv(t *testing.T) {
+	g.RegisterTestingT(t)
+	_, err := ParseTag("env=")
+
+	g.Expect(err).Should(g.MatchError(ErrParseTag))
+}
+
+func TestParseTag_EnvWithBeanName(t *testing.T) {
+	g.RegisterTestingT(t)
+	_, err := ParseTag("env=NAME;beanName=abc")
+
+	g.Expect(err).Should(g.MatchError(ErrParseTag))
+}
@@ -0,0 +1,86 @@
+package types
+
+import "fmt"
+
+// ValueSource loads values from outside the code, e.g. from a config file.
+// Values are keyed by dotted paths such as db.host or servers.0.port.
+type ValueSource interface {
+	Name() string
+	Load() (map[string]interface{}, error)
+}
+
+// ValueLookup is a ValueSource resolving values on demand, so changes made
+// after it was added to a context are visible. Load lists the values known
+// at the moment of the call.
+type ValueLookup interface {
+	ValueSource
+	Lookup(path string) (interface{}, bool)
+}
+
+// DefaultValueSource is a ValueSource providing defaults, used only for paths
+// without a value.
+type DefaultValueSource interface {
+	ValueSource
+	Defaults() map[string]interface{}
+}
+
+// ValueLayer orders values by where they come from. A value of a higher
+// layer overrides the values of lower layers, whatever the loading order.
+type ValueLayer int
+
+const (
+	DefaultLayer ValueLayer = iota
+	FileLayer
+	ProfileLayer
+	EnvLayer
+	FlagLayer
+	ExplicitLayer
+)
+
+func (l ValueLayer) String() string {
+	switch l {
+	case DefaultLayer:
+		return "defaults"
+	case FileLayer:
+		return "file"
+	case ProfileLayer:
+		return "profile"
+	case EnvLayer:
+		return "env"
+	case FlagLayer:
+		return "flags"
+	case ExplicitLayer:
+		return "explicit"
+	default:
+		return fmt.Sprintf("layer %d", int(l))
+	}
+}
+
+// LayeredValueSource is a ValueSource choosing the layer of its values.
+// Sources not implementing it are loaded into FileLayer, lookups into
+// EnvLayer.
+type LayeredValueSource interface {
+	ValueSource
+	Layer() ValueLayer
+}
+
+// ValueLocator is a ValueSource telling where a path is defined, e.g. the
+// position in a file or the name of a variable.
+type ValueLocator interface {
+	ValueSource
+	Locate(path string) string
+}
+
+// ValueOrigin tells where a value came from.
+type ValueOrigin struct {
+	Layer    ValueLayer
+	Source   string
+	Location string
+}
+
+func (o ValueOrigin) String() string {
+	if o.Location == "" {
+		return fmt.Sprintf("%s (%s)", o.Source, o.Layer)
+	}
+	return fmt.Sprintf("%s (%s)", o.Location, o.Layer)
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/xbl4de/yadi/types"
 	"reflect"
 	"strings"
+	"unicode"
 )
 
 func ValidateTypeIsBean(beanType reflect.Type) error {
@@ -63,3 +64,16 @@ func Capitalize(s string) string {
 	}
 	return strings.ToUpper(string(s[0])) + s[1:]
 }
+
+// LowerCamel lowercases the leading capitals of s, e.g. Port -> port and
+// URLPath -> urlPath.
+func LowerCamel(s string) string {
+	runes := []rune(s)
+	for i := 0; i < len(runes) && unicode.IsUpper(runes[i]); i++ {
+		if i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+			break
+		}
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
@@ -0,0 +1,148 @@
+package yadi
+
+import (
+	g "github.com/onsi/gomega"
+	"github.com/xbl4de/yadi/types"
+	"strings"
+	"testing"
+)
+
+const layeredJSON = `{
+  "db": {
+    "host": "file-host",
+    "port": 5433,
+    "user": "file-user",
+    "name": "file-name"
+  }
+}`
+
+func TestValueLayers_Precedence(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	t.Setenv("YADITEST_DB_HOST", "env-host")
+	t.Setenv("YADITEST_DB_PORT", "5434")
+	t.Setenv("YADITEST_DB_USER", "env-user")
+	source := newTestFlagSource()
+	err := source.Parse([]string{"--set", "db.port=5435", "--set", "db.user=flag-user"})
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	SetValue("db.user", "code-user")
+	err = LoadValues(source, NewEnvSource(WithEnvPrefix("YADITEST")),
+		JSONSource("config.json", strings.NewReader(layeredJSON)))
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	SetDefaultValue("db.name", "default-name")
+	SetDefaultValue("db.schema", "public")
+	UseLazyContext()
+
+	g.Expect(GetValue[string]("db.schema")).Should(g.Equal("public"))
+	g.Expect(GetValue[string]("db.name")).Should(g.Equal("file-name"))
+	g.Expect(GetValue[string]("db.host")).Should(g.Equal("env-host"))
+	g.Expect(GetValue[int]("db.port")).Should(g.Equal(5435))
+	g.Expect(GetValue[string]("db.user")).Should(g.Equal("code-user"))
+}
+
+func TestValueLayers_SameLayer_LaterSourceWins(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	err := LoadValues(
+		JSONSource("base.json", strings.NewReader(`{"db": {"host": "base", "port": 1}}`)),
+		YAMLSource("local.yaml", strings.NewReader("db:\n  host: local\n")))
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	UseLazyContext()
+
+	g.Expect(GetValue[string]("db.host")).Should(g.Equal("local"))
+	g.Expect(GetValue[int]("db.port")).Should(g.Equal(1))
+}
+
+func TestValueOrigin(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	t.Setenv("YADITEST_DB_HOST", "env-host")
+	source := newTestFlagSource()
+	err := source.Parse([]string{"--server.port=9090", "--set", "db.user=admin"})
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	err = LoadValues(
+		JSONSource("config.json", strings.NewReader(layeredJSON)),
+		YAMLSource("config.yaml", strings.NewReader("cache:\n  size: 10\n")),
+		NewEnvSource(WithEnvPrefix("YADITEST")),
+		source)
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	SetValue("app.name", "demo")
+	SetDefaultValue("app.version", "1.0")
+	UseLazyContext()
+
+	expected := map[string]types.ValueOrigin{
+		"db.port":     {Layer: types.FileLayer, Source: "config.json", Location: "config.json:4:13"},
+		"cache.size":  {Layer: types.FileLayer, Source: "config.yaml", Location: "config.yaml:2:9"},
+		"db.host":     {Layer: types.EnvLayer, Source: "env", Location: "YADITEST_DB_HOST"},
+		"server.port": {Layer: types.FlagLayer, Source: "flags", Location: "--server.port"},
+		"db.user":     {Layer: types.FlagLayer, Source: "flags", Location: "--set db.user"},
+		"server.host": {Layer: types.DefaultLayer, Source: "flags", Location: "--server.host"},
+		"app.name":    {Layer: types.ExplicitLayer, Source: "code"},
+		"app.version": {Layer: types.DefaultLayer, Source: "code"},
+	}
+	for path, origin := range expected {
+		g.Expect(ValueOrigin(path)).Should(g.Equal(origin), path)
+	}
+}
+
+func TestValueOrigin_String(t *testing.T) {
+	g.RegisterTestingT(t)
+
+	g.Expect(types.ValueOrigin{Layer: types.FileLayer, Source: "config.json", Location: "config.json:4:13"}.String()).
+		Should(g.Equal("config.json:4:13 (file)"))
+	g.Expect(types.ValueOrigin{Layer: types.ExplicitLayer, Source: "code"}.String()).
+		Should(g.Equal("code (explicit)"))
+}
+
+func TestValueOrigin_NestedValues(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	SetValue("db.host", "localhost")
+	UseLazyContext()
+
+	_, err := ValueOrigin("db")
+	g.Expect(err).Should(g.MatchError(types.ErrNoValueFound))
+
+	_, err = ValueOrigin("missing")
+	g.Expect(err).Should(g.MatchError(types.ErrNoValueFound))
+}
+
+func TestValueOrigin_ConversionErrorNamesOrigin(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	err := LoadValues(YAMLSource("config.yaml", strings.NewReader("db:\n  port: abc\n")))
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	UseLazyContext()
+
+	_, err = GetValue[int]("db.port")
+
+	g.Expect(err).Should(g.MatchError(types.ErrValueConversion))
+	g.Expect(err.Error()).Should(g.ContainSubstring("config.yaml:2:9"))
+}
+
+func TestDeleteValue_RemovesAllLayers(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	SetDefaultValue("db.host", "default")
+	err := LoadValues(JSONSource("config.json", strings.NewReader(layeredJSON)))
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	SetValue("db.host", "code")
+	UseLazyContext()
+
+	DeleteValue("db.host")
+
+	_, err = GetValue[string]("db.host")
+	g.Expect(err).Should(g.MatchError(types.ErrNoValueFound))
+}
+
+func TestContainer_ChildValuesShadowParentLayers(t *testing.T) {
+	t.Parallel()
+	gt := g.NewWithT(t)
+	parent := NewContainer()
+	parent.SetValue("db.host", "parent")
+	child := parent.Child()
+	child.SetDefaultValue("db.host", "child")
+
+	gt.Expect(Value[string](child, "db.host")).Should(g.Equal("child"))
+	gt.Expect(child.ValueOrigin("db.host")).Should(g.Equal(types.ValueOrigin{Layer: types.DefaultLayer, Source: "code"}))
+}
@@ -0,0 +1,464 @@
+package yadi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/xbl4de/yadi/types"
+	"gopkg.in/yaml.v3"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type documentParser func(name string, data []byte) (map[string]interface{}, map[string]string, error)
+
+// documentSource reads a JSON or YAML document and flattens it into dotted
+// paths. Null values are skipped, so they don't shadow defaults.
+type documentSource struct {
+	name      string
+	read      func() ([]byte, error)
+	parse     documentParser
+	locations map[string]string
+}
+
+func (s *documentSource) Name() string {
+	return s.name
+}
+
+func (s *documentSource) Layer() types.ValueLayer {
+	return types.FileLayer
+}
+
+// Locate returns the position of the path in the document as name:line:col,
+// known after Load.
+func (s *documentSource) Locate(path string) string {
+	return s.locations[path]
+}
+
+func (s *documentSource) Load() (map[string]interface{}, error) {
+	if s.parse == nil {
+		return nil, fmt.Errorf("%w: %s: unsupported format, expected .json, .yaml or .yml", types.ErrLoadValues, s.name)
+	}
+	data, err := s.read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", types.ErrLoadValues, s.name, err)
+	}
+	values, locations, err := s.parse(s.name, data)
+	if err != nil {
+		return nil, err
+	}
+	s.locations = locations
+	return values, nil
+}
+
+func JSONSource(name string, reader io.Reader) types.ValueSource {
+	return &documentSource{name: name, read: readAll(reader), parse: parseJSON}
+}
+
+func YAMLSource(name string, reader io.Reader) types.ValueSource {
+	return &documentSource{name: name, read: readAll(reader), parse: parseYAML}
+}
+
+// FileSource reads a JSON or YAML file, the format is chosen by extension.
+func FileSource(path string) types.ValueSource {
+	return &documentSource{
+		name: path,
+		read: func() ([]byte, error) {
+			return os.ReadFile(path)
+		},
+		parse: parserFor(path),
+	}
+}
+
+// FSSource reads a JSON or YAML file from fsys, e.g. an embed.FS.
+func FSSource(fsys fs.FS, path string) types.ValueSource {
+	return &documentSource{
+		name: path,
+		read: func() ([]byte, error) {
+			return fs.ReadFile(fsys, path)
+		},
+		parse: parserFor(path),
+	}
+}
+
+func readAll(reader io.Reader) func() ([]byte, error) {
+	return func() ([]byte, error) {
+		return io.ReadAll(reader)
+	}
+}
+
+func parserFor(path string) documentParser {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return parseJSON
+	case ".yaml", ".yml":
+		return parseYAML
+	default:
+		return nil
+	}
+}
+
+func parseJSON(name string, data []byte) (map[string]interface{}, map[string]string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	parser := &jsonParser{
+		name:      name,
+		data:      data,
+		decoder:   decoder,
+		values:    make(map[string]interface{}),
+		locations: make(map[string]string),
+	}
+	token, offset, err := parser.next()
+	if err != nil {
+		return nil, nil, err
+	}
+	if token != json.Delim('{') {
+		line, column := position(data, offset)
+		return nil, nil, fmt.Errorf("%w: %s:%d:%d: expected an object at the top level", types.ErrLoadValues, name, line, column)
+	}
+	err = parser.object("", offset)
+	if err != nil {
+		return nil, nil, err
+	}
+	if decoder.More() {
+		line, column := position(data, decoder.InputOffset())
+		return nil, nil, fmt.Errorf("%w: %s:%d:%d: unexpected data after the document", types.ErrLoadValues, name, line, column)
+	}
+	return parser.values, parser.locations, nil
+}
+
+// jsonParser flattens a JSON document token by token to know where each
+// value starts.
+type jsonParser struct {
+	name      string
+	data      []byte
+	decoder   *json.Decoder
+	values    map[string]interface{}
+	locations map[string]string
+}
+
+// next reads a token and returns the offset it starts at.
+func (p *jsonParser) next() (json.Token, int64, error) {
+	offset := p.decoder.InputOffset()
+	for offset < int64(len(p.data)) && strings.IndexByte(" \t\r\n:,", p.data[offset]) >= 0 {
+		offset++
+	}
+	token, err := p.decoder.Token()
+	if err != nil {
+		return nil, offset, jsonError(p.name, p.data, err)
+	}
+	return token, offset, nil
+}
+
+func (p *jsonParser) value(path string, token json.Token, offset int64) error {
+	switch typed := token.(type) {
+	case json.Delim:
+		if typed == '{' {
+			return p.object(path, offset)
+		}
+		return p.array(path, offset)
+	case json.Number:
+		if number, err := typed.Int64(); err == nil {
+			p.set(path, number, offset)
+		} else if number, err := typed.Float64(); err == nil {
+			p.set(path, number, offset)
+		} else {
+			p.set(path, typed.String(), offset)
+		}
+	case nil:
+	default:
+		p.set(path, typed, offset)
+	}
+	return nil
+}
+
+func (p *jsonParser) object(path string, offset int64) error {
+	empty := true
+	for p.decoder.More() {
+		empty = false
+		key, _, err := p.next()
+		if err != nil {
+			return err
+		}
+		token, valueOffset, err := p.next()
+		if err != nil {
+			return err
+		}
+		err = p.value(joinPath(path, key.(string)), token, valueOffset)
+		if err != nil {
+			return err
+		}
+	}
+	if empty && path != "" {
+		p.set(path, map[string]interface{}{}, offset)
+	}
+	_, _, err := p.next()
+	return err
+}
+
+func (p *jsonParser) array(path string, offset int64) error {
+	i := 0
+	for ; p.decoder.More(); i++ {
+		token, valueOffset, err := p.next()
+		if err != nil {
+			return err
+		}
+		err = p.value(joinPath(path, strconv.Itoa(i)), token, valueOffset)
+		if err != nil {
+			return err
+		}
+	}
+	if i == 0 {
+		p.set(path, []interface{}{}, offset)
+	}
+	_, _, err := p.next()
+	return err
+}
+
+func (p *jsonParser) set(path string, value interface{}, offset int64) {
+	line, column := position(p.data, offset)
+	p.values[path] = value
+	p.locations[path] = fmt.Sprintf("%s:%d:%d", p.name, line, column)
+}
+
+func jsonError(name string, data []byte, err error) error {
+	var offset int64 = -1
+	switch typed := err.(type) {
+	case *json.SyntaxError:
+		// the offset points past the invalid character, or at the end of a
+		// truncated document
+		offset = typed.Offset
+		if offset < int64(len(data)) {
+			offset = max(offset-1, 0)
+		}
+	case *json.UnmarshalTypeError:
+		offset = typed.Offset
+	}
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		offset = int64(len(data))
+		err = io.ErrUnexpectedEOF
+	}
+	if offset < 0 {
+		return fmt.Errorf("%w: %s: %w", types.ErrLoadValues, name, err)
+	}
+	line, column := position(data, offset)
+	return fmt.Errorf("%w: %s:%d:%d: %w", types.ErrLoadValues, name, line, column, err)
+}
+
+func position(data []byte, offset int64) (int, int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := len(before) - bytes.LastIndexByte(before, '\n')
+	return line, column
+}
+
+func parseYAML(name string, data []byte) (map[string]interface{}, map[string]string, error) {
+	var document yaml.Node
+	err := yaml.Unmarshal(data, &document)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %s: %w", types.ErrLoadValues, name, err)
+	}
+	values := make(map[string]interface{})
+	locations := make(map[string]string)
+	if len(document.Content) == 0 {
+		return values, locations, nil
+	}
+	root := resolveAlias(document.Content[0])
+	if root.Kind != yaml.MappingNode {
+		return nil, nil, yamlError(name, root, "expected a mapping at the top level")
+	}
+	err = flattenYAML(name, "", root, values, locations)
+	if err != nil {
+		return nil, nil, err
+	}
+	return values, locations, nil
+}
+
+func flattenYAML(name string, path string, node *yaml.Node, values map[string]interface{}, locations map[string]string) error {
+	node = resolveAlias(node)
+	switch node.Kind {
+	case yaml.MappingNode:
+		entries, err := yamlMappingEntries(name, node)
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 && path != "" {
+			values[path] = map[string]interface{}{}
+			locations[path] = yamlLocation(name, node)
+		}
+		for _, key := range slices.Sorted(maps.Keys(entries)) {
+			err = flattenYAML(name, joinPath(path, key), entries[key], values, locations)
+			if err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode:
+		if len(node.Content) == 0 {
+			values[path] = []interface{}{}
+			locations[path] = yamlLocation(name, node)
+		}
+		for i, item := range node.Content {
+			err := flattenYAML(name, joinPath(path, strconv.Itoa(i)), item, values, locations)
+			if err != nil {
+				return err
+			}
+		}
+	case yaml.ScalarNode:
+		var value interface{}
+		err := node.Decode(&value)
+		if err != nil {
+			return yamlError(name, node, fmt.Sprintf("invalid value of %s: %s", path, err))
+		}
+		if value != nil {
+			values[path] = value
+			locations[path] = yamlLocation(name, node)
+		}
+	}
+	return nil
+}
+
+// yamlMappingEntries returns the entries of a mapping, including the ones
+// merged with the "<<" key.
+func yamlMappingEntries(name string, node *yaml.Node) (map[string]*yaml.Node, error) {
+	entries := make(map[string]*yaml.Node)
+	explicit := make(map[string]bool)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if key.Kind != yaml.ScalarNode {
+			return nil, yamlError(name, key, "expected a scalar key")
+		}
+		if key.Tag == "!!merge" {
+			err := mergeYAMLEntries(name, value, entries)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if explicit[key.Value] {
+			return nil, yamlError(name, key, fmt.Sprintf("duplicate key %s", key.Value))
+		}
+		explicit[key.Value] = true
+		entries[key.Value] = value
+	}
+	return entries, nil
+}
+
+func mergeYAMLEntries(name string, node *yaml.Node, entries map[string]*yaml.Node) error {
+	node = resolveAlias(node)
+	sources := []*yaml.Node{node}
+	if node.Kind == yaml.SequenceNode {
+		sources = node.Content
+	}
+	for _, source := range sources {
+		source = resolveAlias(source)
+		if source.Kind != yaml.MappingNode {
+			return yamlError(name, source, "expected a mapping to merge")
+		}
+		merged, err := yamlMappingEntries(name, source)
+		if err != nil {
+			return err
+		}
+		for key, value := range merged {
+			if _, ok := entries[key]; !ok {
+				entries[key] = value
+			}
+		}
+	}
+	return nil
+}
+
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	return node
+}
+
+func yamlLocation(name string, node *yaml.Node) string {
+	return fmt.Sprintf("%s:%d:%d", name, node.Line, node.Column)
+}
+
+func yamlError(name string, node *yaml.Node, message string) error {
+	return fmt.Errorf("%w: %s:%d:%d: %s", types.ErrLoadValues, name, node.Line, node.Column, message)
+}
+
+// loadedSource holds the values read from a source, or just the source if it
+// resolves values on demand.
+type loadedSource struct {
+	source   types.ValueSource
+	lookup   bool
+	values   map[string]interface{}
+	defaults map[string]interface{}
+}
+
+// loadValues applies the values of the sources to ctx in order. Each source
+// sets its values in its layer, so later sources override earlier ones of the
+// same layer only.
+func loadValues(ctx types.Context, sources []types.ValueSource) error {
+	loaded, err := readSources(sources)
+	if err != nil {
+		return err
+	}
+	applyValues(ctx, loaded)
+	return nil
+}
+
+func readSources(sources []types.ValueSource) ([]loadedSource, error) {
+	loaded := make([]loadedSource, 0, len(sources))
+	for _, source := range sources {
+		if _, ok := source.(types.ValueLookup); ok {
+			loaded = append(loaded, loadedSource{source: source, lookup: true})
+			continue
+		}
+		values, err := source.Load()
+		if err != nil {
+			return nil, err
+		}
+		var defaults map[string]interface{}
+		if defaultSource, ok := source.(types.DefaultValueSource); ok {
+			defaults = defaultSource.Defaults()
+		}
+		loaded = append(loaded, loadedSource{source: source, values: values, defaults: defaults})
+	}
+	return loaded, nil
+}
+
+func applyValues(ctx types.Context, loaded []loadedSource) {
+	for _, source := range loaded {
+		if source.lookup {
+			ctx.AddValueLookup(source.source.(types.ValueLookup))
+			continue
+		}
+		layer := sourceLayer(source.source, types.FileLayer)
+		for _, path := range slices.Sorted(maps.Keys(source.values)) {
+			ctx.SetLayeredValue(path, source.values[path], sourceOrigin(source.source, layer, path))
+		}
+		for _, path := range slices.Sorted(maps.Keys(source.defaults)) {
+			ctx.SetLayeredValue(path, source.defaults[path], sourceOrigin(source.source, types.DefaultLayer, path))
+		}
+	}
+}
+
+func sourceLayer(source types.ValueSource, fallback types.ValueLayer) types.ValueLayer {
+	if layered, ok := source.(types.LayeredValueSource); ok {
+		return layered.Layer()
+	}
+	return fallback
+}
+
+func sourceOrigin(source types.ValueSource, layer types.ValueLayer, path string) types.ValueOrigin {
+	origin := types.ValueOrigin{Layer: layer, Source: source.Name()}
+	if locator, ok := source.(types.ValueLocator); ok {
+		origin.Location = locator.Locate(path)
+	}
+	return origin
+}
@@ -0,0 +1,186 @@
+package yadi
+
+import (
+	g "github.com/onsi/gomega"
+	"github.com/xbl4de/yadi/types"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+const yamlConfig = `
+defaults: &defaults
+  timeout: 5s
+db:
+  host: localhost
+  port: 5432
+  user: admin
+  pool:
+    <<: *defaults
+    size: 10
+  replicas:
+    - host: replica-1
+      port: 5433
+    - host: replica-2
+  password: ~
+`
+
+const jsonConfig = `{
+  "db": {
+    "host": "remote",
+    "port": 6543,
+    "ratio": 0.5,
+    "tags": []
+  }
+}`
+
+type LoadedDatabase struct {
+	Config DatabaseConfig `yadi:"path=db"`
+}
+
+func TestLoadValues_YAML(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	err := LoadValues(YAMLSource("config.yaml", strings.NewReader(yamlConfig)))
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	UseLazyContext()
+
+	database, err := GetBean[*LoadedDatabase]()
+
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(database.Config.Host).Should(g.Equal("localhost"))
+	g.Expect(database.Config.Port).Should(g.Equal(5432))
+	g.Expect(database.Config.Pool).Should(g.Equal(PoolConfig{Size: 10, Timeout: 5 * time.Second}))
+	g.Expect(database.Config.Replicas).Should(g.HaveLen(2))
+	_, err = GetValue[string]("db.password")
+	g.Expect(err).Should(g.MatchError(types.ErrNoValueFound))
+}
+
+func TestLoadValues_JSON_AfterContextCreated(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	UseLazyContext()
+
+	err := LoadValues(
+		YAMLSource("config.yaml", strings.NewReader(yamlConfig)),
+		JSONSource("config.json", strings.NewReader(jsonConfig)),
+	)
+
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(GetValue[string]("db.host")).Should(g.Equal("remote"))
+	g.Expect(GetValue[int]("db.port")).Should(g.Equal(6543))
+	g.Expect(GetValue[string]("db.user")).Should(g.Equal("admin"))
+	g.Expect(GetValue[float64]("db.ratio")).Should(g.Equal(0.5))
+	g.Expect(GetValue[[]string]("db.tags")).Should(g.BeEmpty())
+}
+
+func TestLoadValues_FileSource(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	path := filepath.Join(t.TempDir(), "config.yml")
+	g.Expect(os.WriteFile(path, []byte(yamlConfig), 0o600)).Should(g.Succeed())
+	UseLazyContext()
+
+	err := LoadValues(FileSource(path))
+
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(GetValue[string]("db.replicas.1.host")).Should(g.Equal("replica-2"))
+}
+
+func TestLoadValues_FSSource(t *testing.T) {
+	t.Parallel()
+	gt := g.NewWithT(t)
+	fsys := fstest.MapFS{
+		"config/app.json": {Data: []byte(jsonConfig)},
+	}
+	c := NewContainer()
+
+	err := c.LoadValues(FSSource(fsys, "config/app.json"))
+
+	gt.Expect(err).ShouldNot(g.HaveOccurred())
+	gt.Expect(Value[string](c, "db.host")).Should(g.Equal("remote"))
+}
+
+func TestLoadValues_Errors(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name     string
+		source   types.ValueSource
+		expected string
+	}{
+		{
+			name:     "json syntax",
+			source:   JSONSource("config.json", strings.NewReader("{\n  \"db\": {\n    \"host\" \"localhost\"\n  }\n}")),
+			expected: "config.json:3:12",
+		},
+		{
+			name:     "json truncated",
+			source:   JSONSource("config.json", strings.NewReader("{\n  \"db\": {")),
+			expected: "config.json:2:10",
+		},
+		{
+			name:     "json trailing data",
+			source:   JSONSource("config.json", strings.NewReader("{}\n{}")),
+			expected: "config.json:2:1: unexpected data",
+		},
+		{
+			name:     "json top level",
+			source:   JSONSource("config.json", strings.NewReader("[1, 2]")),
+			expected: "config.json:1:1",
+		},
+		{
+			name:     "yaml syntax",
+			source:   YAMLSource("config.yaml", strings.NewReader("db:\n  host: [localhost\n")),
+			expected: "config.yaml: yaml: line",
+		},
+		{
+			name:     "yaml duplicate key",
+			source:   YAMLSource("config.yaml", strings.NewReader("db:\n  host: a\n  host: b\n")),
+			expected: "config.yaml:3:3: duplicate key host",
+		},
+		{
+			name:     "yaml complex key",
+			source:   YAMLSource("config.yaml", strings.NewReader("db:\n  ? [a, b]\n  : c\n")),
+			expected: "config.yaml:2:5",
+		},
+		{
+			name:     "missing file",
+			source:   FileSource(filepath.Join(t.TempDir(), "missing.yaml")),
+			expected: "missing.yaml",
+		},
+		{
+			name:     "unsupported format",
+			source:   FileSource("config.toml"),
+			expected: "config.toml: unsupported format",
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			gt := g.NewWithT(t)
+
+			err := NewContainer().LoadValues(testCase.source)
+
+			gt.Expect(err).Should(g.MatchError(types.ErrLoadValues))
+			gt.Expect(err.Error()).Should(g.ContainSubstring(testCase.expected))
+		})
+	}
+}
+
+func TestLoadValues_ErrorBeforeContext_NothingDeferred(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+
+	err := LoadValues(
+		YAMLSource("config.yaml", strings.NewReader(yamlConfig)),
+		JSONSource("config.json", strings.NewReader("{")),
+	)
+	UseLazyContext()
+
+	g.Expect(err).Should(g.MatchError(types.ErrLoadValues))
+	_, err = GetValue[string]("db.host")
+	g.Expect(err).Should(g.MatchError(types.ErrNoValueFound))
+}
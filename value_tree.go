@@ -0,0 +1,181 @@
+package yadi
+
+import (
+	"github.com/xbl4de/yadi/types"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// valueTree stores values by dot-separated paths. Generic maps and lists set
+// at a path are expanded into sub-paths, so nested documents can be read by
+// full path and assembled back by prefix. It is guarded by its owner.
+type valueTree struct {
+	root *valueNode
+}
+
+type valueNode struct {
+	value    interface{}
+	origin   types.ValueOrigin
+	hasValue bool
+	children map[string]*valueNode
+}
+
+func newValueTree() *valueTree {
+	return &valueTree{root: &valueNode{}}
+}
+
+func splitPath(path string) []string {
+	return strings.Split(path, ".")
+}
+
+func (t *valueTree) node(path string) (*valueNode, bool) {
+	node := t.root
+	for _, part := range splitPath(path) {
+		child, ok := node.children[part]
+		if !ok {
+			return nil, false
+		}
+		node = child
+	}
+	return node, true
+}
+
+// set replaces the value and everything stored under path. The values
+// expanded from value share its origin.
+func (t *valueTree) set(path string, value interface{}, origin types.ValueOrigin) {
+	node := t.root.descend(splitPath(path))
+	node.value = nil
+	node.hasValue = false
+	node.children = nil
+	node.assign(value, origin)
+}
+
+func (t *valueTree) delete(path string) {
+	parts := splitPath(path)
+	nodes := []*valueNode{t.root}
+	for _, part := range parts {
+		child, ok := nodes[len(nodes)-1].children[part]
+		if !ok {
+			return
+		}
+		nodes = append(nodes, child)
+	}
+	for i := len(parts) - 1; i >= 0; i-- {
+		delete(nodes[i].children, parts[i])
+		if nodes[i].hasValue || len(nodes[i].children) > 0 {
+			return
+		}
+	}
+}
+
+// leaves returns the values stored under prefix keyed by their path relative
+// to it. An empty prefix returns every value.
+func (t *valueTree) leaves(prefix string) map[string]interface{} {
+	values := make(map[string]interface{})
+	node := t.root
+	if prefix != "" {
+		var ok bool
+		node, ok = t.node(prefix)
+		if !ok {
+			return values
+		}
+	}
+	node.collect("", values)
+	return values
+}
+
+func (n *valueNode) descend(parts []string) *valueNode {
+	node := n
+	for _, part := range parts {
+		child, ok := node.children[part]
+		if !ok {
+			if node.children == nil {
+				node.children = make(map[string]*valueNode)
+			}
+			child = &valueNode{}
+			node.children[part] = child
+		}
+		node = child
+	}
+	return node
+}
+
+func (n *valueNode) assign(value interface{}, origin types.ValueOrigin) {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		if len(typed) > 0 {
+			for key, item := range typed {
+				n.descend(splitPath(key)).assign(item, origin)
+			}
+			return
+		}
+	case []interface{}:
+		if len(typed) > 0 {
+			for i, item := range typed {
+				n.descend([]string{strconv.Itoa(i)}).assign(item, origin)
+			}
+			return
+		}
+	}
+	n.value = value
+	n.origin = origin
+	n.hasValue = true
+}
+
+func (n *valueNode) collect(path string, values map[string]interface{}) {
+	for key, child := range n.children {
+		childPath := key
+		if path != "" {
+			childPath = path + "." + key
+		}
+		if child.hasValue {
+			values[childPath] = child.value
+		}
+		child.collect(childPath, values)
+	}
+}
+
+// pathNode is a map assembled from sub-paths, as opposed to a map stored by
+// the user.
+type pathNode map[string]interface{}
+
+func expandPaths(values map[string]interface{}) interface{} {
+	root := pathNode{}
+	for _, path := range slices.Sorted(maps.Keys(values)) {
+		parts := splitPath(path)
+		node := root
+		for _, part := range parts[:len(parts)-1] {
+			child, ok := node[part].(pathNode)
+			if !ok {
+				child = pathNode{}
+				node[part] = child
+			}
+			node = child
+		}
+		node[parts[len(parts)-1]] = values[path]
+	}
+	return root.finish()
+}
+
+// finish turns nodes into maps, or into lists if their keys are exactly the
+// indexes 0..n-1.
+func (n pathNode) finish() interface{} {
+	result := make(map[string]interface{}, len(n))
+	for key, value := range n {
+		if child, ok := value.(pathNode); ok {
+			value = child.finish()
+		}
+		result[key] = value
+	}
+	list := make([]interface{}, len(result))
+	for key, value := range result {
+		index, err := strconv.Atoi(key)
+		if err != nil || index < 0 || index >= len(list) || strconv.Itoa(index) != key {
+			return result
+		}
+		list[index] = value
+	}
+	return list
+}
@@ -0,0 +1,147 @@
+package yadi
+
+import (
+	g "github.com/onsi/gomega"
+	"github.com/xbl4de/yadi/types"
+	"testing"
+)
+
+func TestValueTree_SetMap_ReadByPath(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	SetValue("db", map[string]any{
+		"host": "localhost",
+		"pool": map[string]any{"size": 10},
+		"replicas": []any{
+			map[string]any{"host": "replica-1"},
+		},
+	})
+	UseLazyContext()
+
+	host, err := GetValue[string]("db.host")
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(host).Should(g.Equal("localhost"))
+
+	size, err := GetValue[int]("db.pool.size")
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(size).Should(g.Equal(10))
+
+	replica, err := GetValue[string]("db.replicas.0.host")
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(replica).Should(g.Equal("replica-1"))
+}
+
+func TestValueTree_SetByPath_ReadMap(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	SetValue("db.host", "localhost")
+	SetValue("db.port", 5432)
+	UseLazyContext()
+
+	db, err := GetValue[map[string]any]("db")
+
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(db).Should(g.Equal(map[string]any{"host": "localhost", "port": 5432}))
+}
+
+func TestValueTree_SetReplacesSubtree(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	SetValue("db.host", "localhost")
+	SetValue("db.port", 5432)
+	SetValue("db", map[string]any{"host": "remote"})
+	UseLazyContext()
+
+	_, err := GetValue[int]("db.port")
+	g.Expect(err).Should(g.MatchError(types.ErrNoValueFound))
+
+	host, err := GetValue[string]("db.host")
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(host).Should(g.Equal("remote"))
+}
+
+func TestValueTree_GetValuesByPrefix(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	SetValue("db", map[string]any{
+		"host": "localhost",
+		"pool": map[string]any{"size": 10},
+	})
+	SetValue("dbx", "not under db")
+	UseLazyContext()
+
+	values, err := GetValuesByPrefix("db")
+
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(values).Should(g.Equal(map[string]interface{}{
+		"host":      "localhost",
+		"pool.size": 10,
+	}))
+}
+
+func TestValueTree_Keys(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	SetValue("db", map[string]any{"host": "localhost", "port": 5432})
+	SetValue("app.name", "yadi")
+	UseLazyContext()
+
+	keys, err := GetValueKeys()
+
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(keys).Should(g.Equal([]string{"app.name", "db.host", "db.port"}))
+}
+
+func TestValueTree_DeleteValue(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	SetValue("db", map[string]any{"host": "localhost", "port": 5432})
+	SetValue("app.name", "yadi")
+	DeleteValue("app.name")
+	UseLazyContext()
+
+	DeleteValue("db.port")
+
+	keys, err := GetValueKeys()
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(keys).Should(g.Equal([]string{"db.host"}))
+
+	DeleteValue("db")
+
+	_, err = GetValue[map[string]any]("db")
+	g.Expect(err).Should(g.MatchError(types.ErrNoValueFound))
+	keys, err = GetValueKeys()
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(keys).Should(g.BeEmpty())
+}
+
+func TestValueTree_EmptyCollections_StoredAsValues(t *testing.T) {
+	g.RegisterTestingT(t)
+	ResetYadi()
+	SetValue("tags", []any{})
+	SetValue("labels", map[string]any{})
+	UseLazyContext()
+
+	tags, err := GetValue[[]string]("tags")
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(tags).Should(g.BeEmpty())
+
+	labels, err := GetValue[map[string]string]("labels")
+	g.Expect(err).ShouldNot(g.HaveOccurred())
+	g.Expect(labels).Should(g.BeEmpty())
+}
+
+func TestValueTree_ChildContext(t *testing.T) {
+	t.Parallel()
+	gt := g.NewWithT(t)
+	parent := NewContainer()
+	parent.SetValue("db", map[string]any{"host": "localhost", "port": 5432})
+	child := parent.Child()
+	child.SetValue("db.host", "remote")
+	child.DeleteValue("db.port")
+
+	gt.Expect(child.ValueKeys()).Should(g.Equal([]string{"db.host", "db.port"}))
+	gt.Expect(Value[string](child, "db.host")).Should(g.Equal("remote"))
+	gt.Expect(Value[int](child, "db.port")).Should(g.Equal(5432))
+	gt.Expect(Value[string](parent, "db.host")).Should(g.Equal("localhost"))
+}